@@ -14,3 +14,116 @@ type ConfigurationChange interface {
 	Describe() (ChangeOp, string)
 	Execute(ctx context.Context, c Clients) error
 }
+
+// TargetedChange is implemented by changes that can tell what they apply to,
+// see ChangeTargetOf.
+type TargetedChange interface {
+	Target() ChangeTarget
+}
+
+// Name returns the name of the operation, "add", "update" or "remove".
+func (op ChangeOp) Name() string {
+	switch op {
+	case OpAdd:
+		return "add"
+	case OpUpdate:
+		return "update"
+	case OpRemove:
+		return "remove"
+	default:
+		return string(op)
+	}
+}
+
+// IsDestructive checks if a change removes something from the repository,
+// downgrades schemas, registers a schema generation without some of the
+// current schemas or exemplars, or removes expressions, variants or eviction
+// from a type configuration.
+func IsDestructive(c ConfigurationChange) bool {
+	op, _ := c.Describe()
+	if op == OpRemove {
+		return true
+	}
+
+	switch c := c.(type) {
+	case generationChange:
+		return len(c.downgrades()) > 0 || len(c.removals()) > 0
+	case *TypeConfigurationChange:
+		return len(c.removals()) > 0
+	default:
+		return false
+	}
+}
+
+// ChangeDomain is the configuration domain that a change belongs to.
+type ChangeDomain string
+
+const (
+	DomainSchema     ChangeDomain = "schema"
+	DomainStatus     ChangeDomain = "status"
+	DomainWorkflow   ChangeDomain = "workflow"
+	DomainMetaType   ChangeDomain = "meta_type"
+	DomainMetric     ChangeDomain = "metric"
+	DomainTypeConfig ChangeDomain = "type_config"
+)
+
+// changeDomain returns the domain of a change, or an empty string for
+// changes that weren't created by eleconf.
+func changeDomain(c ConfigurationChange) ChangeDomain {
+	switch c.(type) {
+	case generationChange:
+		return DomainSchema
+	case statusChange:
+		return DomainStatus
+	case *DocWorkflowUpdate:
+		return DomainWorkflow
+	case metaTypeChange:
+		return DomainMetaType
+	case *MetricUpdate:
+		return DomainMetric
+	case *TypeConfigurationChange:
+		return DomainTypeConfig
+	default:
+		return ""
+	}
+}
+
+// TargetKind is the kind of repository object a change applies to.
+type TargetKind string
+
+const (
+	TargetSchemas      TargetKind = "schemas"
+	TargetDocumentType TargetKind = "document_type"
+	TargetMetric       TargetKind = "metric"
+)
+
+// ChangeTarget identifies the repository object that a change applies to.
+// Name is empty for schema changes, and for targets that cover a whole
+// domain.
+type ChangeTarget struct {
+	Kind TargetKind `json:"kind"`
+	Name string     `json:"name,omitempty"`
+}
+
+// ChangeTargetOf returns what a change applies to, used to check it against
+// the managed scope. Changes that don't implement TargetedChange get the
+// target of their whole domain, or an empty target if eleconf didn't create
+// them.
+func ChangeTargetOf(c ConfigurationChange) ChangeTarget {
+	t, ok := c.(TargetedChange)
+	if ok {
+		return t.Target()
+	}
+
+	switch c.(type) {
+	case generationChange:
+		return ChangeTarget{Kind: TargetSchemas}
+	case *MetricUpdate:
+		return ChangeTarget{Kind: TargetMetric}
+	case statusChange, *DocWorkflowUpdate, metaTypeChange,
+		*TypeConfigurationChange:
+		return ChangeTarget{Kind: TargetDocumentType}
+	default:
+		return ChangeTarget{}
+	}
+}
@@ -0,0 +1,176 @@
+package eleconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ttab/eleconf"
+	"github.com/ttab/elephant-api/repository"
+)
+
+func TestIsDestructive(t *testing.T) {
+	ctx := t.Context()
+	clients := fakeClients(newFakeRepository())
+
+	conf := &eleconf.Config{}
+
+	loadSchemas := func(versions map[string]string) []eleconf.LoadedSchema {
+		var schemas []eleconf.LoadedSchema
+
+		for name, v := range versions {
+			schemas = append(schemas, eleconf.LoadedSchema{
+				Lock: eleconf.SchemaLock{Name: name, Version: v},
+				Data: []byte("{}"),
+			})
+		}
+
+		return schemas
+	}
+
+	for version, destructive := range map[string]bool{
+		"v1.0.10":     false,
+		"v1.1.0-rc.1": false,
+		"v1.0.5-pre1": true,
+		"v1.0.4":      true,
+		"latest":      true,
+	} {
+		schemas := loadSchemas(map[string]string{
+			"core":          "v1.0.6",
+			"core-planning": "v1.0.6",
+			"tt":            version,
+		})
+
+		changes, err := eleconf.GetSchemaChanges(ctx, clients, conf,
+			schemas, nil, repository.SchemaActivation_ACTIVATION_ACTIVE)
+		if err != nil {
+			t.Fatalf("get schema changes: %v", err)
+		}
+
+		if len(changes) != 1 {
+			t.Fatalf("expected a generation change for %s, got %d changes",
+				version, len(changes))
+		}
+
+		if eleconf.IsDestructive(changes[0]) != destructive {
+			t.Errorf("expected destructive to be %v for tt v1.0.5 => %s",
+				destructive, version)
+		}
+	}
+
+	changes, err := eleconf.GetSchemaChanges(ctx, clients, conf,
+		loadSchemas(map[string]string{
+			"core": "v1.0.6",
+			"tt":   "v1.0.5",
+		}), nil, repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		t.Fatalf("get schema changes: %v", err)
+	}
+
+	if len(changes) != 1 || !eleconf.IsDestructive(changes[0]) {
+		t.Error("expected dropping a schema to be destructive")
+	}
+
+	repo := newFakeRepository()
+	repo.generation = 1
+	repo.exemplars = []*repository.Exemplar{
+		{Name: "article", VersionHash: "sha256:old"},
+	}
+
+	exemplars := []eleconf.LoadedExemplar{{
+		Lock: eleconf.ExemplarLock{
+			Name:    "planning",
+			DocType: "core/planning-item",
+			Hash:    "sha256:new",
+		},
+	}}
+
+	changes, err = eleconf.GetSchemaChanges(ctx, fakeClients(repo), conf,
+		loadSchemas(map[string]string{
+			"core":          "v1.0.6",
+			"core-planning": "v1.0.6",
+			"tt":            "v1.0.5",
+		}), exemplars, repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		t.Fatalf("get schema changes: %v", err)
+	}
+
+	if len(changes) != 1 || !eleconf.IsDestructive(changes[0]) {
+		t.Error("expected dropping an exemplar to be destructive")
+	}
+
+	exemplars = append(exemplars, eleconf.LoadedExemplar{
+		Lock: eleconf.ExemplarLock{
+			Name:    "article",
+			DocType: "core/article",
+			Hash:    "sha256:new",
+		},
+	})
+
+	changes, err = eleconf.GetSchemaChanges(ctx, fakeClients(repo), conf,
+		loadSchemas(map[string]string{
+			"core":          "v1.0.6",
+			"core-planning": "v1.0.6",
+			"tt":            "v1.0.5",
+		}), exemplars, repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		t.Fatalf("get schema changes: %v", err)
+	}
+
+	if len(changes) != 1 || eleconf.IsDestructive(changes[0]) {
+		t.Error("expected updating and adding exemplars not to be destructive")
+	}
+
+	current := eleconf.TypeConfigSpec{
+		TimeExpressions: []eleconf.TimeExpression{
+			{Expression: ".meta(type='core/event').data{start}"},
+		},
+		LabelExpressions: []eleconf.LabelExpression{{
+			Expression: ".meta(type='core/section').data{code}",
+			Template:   "section-{{.code}}",
+		}},
+		Variants:             []string{"timeless"},
+		EvictNoncurrentAfter: 30 * 24 * time.Hour,
+	}
+
+	added := current
+	added.Bounded = true
+	added.Variants = []string{"timeless", "preview"}
+
+	if eleconf.IsDestructive(&eleconf.TypeConfigurationChange{
+		Operation: eleconf.OpUpdate,
+		Type:      "core/article",
+		Current:   current,
+		Wanted:    added,
+	}) {
+		t.Error("expected adding type configuration not to be destructive")
+	}
+
+	for name, edit := range map[string]func(s *eleconf.TypeConfigSpec){
+		"time expressions":  func(s *eleconf.TypeConfigSpec) { s.TimeExpressions = nil },
+		"label expressions": func(s *eleconf.TypeConfigSpec) { s.LabelExpressions = nil },
+		"variants":          func(s *eleconf.TypeConfigSpec) { s.Variants = nil },
+		"eviction":          func(s *eleconf.TypeConfigSpec) { s.EvictNoncurrentAfter = 0 },
+	} {
+		wanted := current
+
+		edit(&wanted)
+
+		if !eleconf.IsDestructive(&eleconf.TypeConfigurationChange{
+			Operation: eleconf.OpUpdate,
+			Type:      "core/article",
+			Current:   current,
+			Wanted:    wanted,
+		}) {
+			t.Errorf("expected removing %s to be destructive", name)
+		}
+	}
+
+	remove := &eleconf.MetricUpdate{
+		Operation: eleconf.OpRemove,
+		Kind:      "charcount",
+	}
+
+	if !eleconf.IsDestructive(remove) {
+		t.Error("expected removals to be destructive")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/eleconf"
+	"github.com/urfave/cli/v3"
+)
+
+// recordedChange is a change that records if it has been executed.
+type recordedChange struct {
+	op       eleconf.ChangeOp
+	executed bool
+}
+
+func (c *recordedChange) Describe() (eleconf.ChangeOp, string) {
+	return c.op, "recorded change"
+}
+
+func (c *recordedChange) Execute(_ context.Context, _ eleconf.Clients) error {
+	c.executed = true
+
+	return nil
+}
+
+// runApply runs displayAndApplyChanges with the given flags, and returns
+// what was written to stdout.
+func runApply(
+	t *testing.T, plan *eleconf.Plan, args ...string,
+) (string, error) {
+	t.Helper()
+
+	outPath := filepath.Join(t.TempDir(), "stdout")
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		t.Fatalf("create stdout file: %v", err)
+	}
+
+	defer out.Close()
+
+	stdout := os.Stdout
+	os.Stdout = out
+
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	cmd := &cli.Command{
+		Name:  "apply",
+		Flags: []cli.Flag{outputFlag(), autoApproveFlag()},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return displayAndApplyChanges(ctx, cmd,
+				&eleconf.StaticClients{}, plan)
+		},
+	}
+
+	runErr := cmd.Run(t.Context(), append([]string{"apply"}, args...))
+
+	_, err = out.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatalf("rewind stdout file: %v", err)
+	}
+
+	written, err := io.ReadAll(out)
+	if err != nil {
+		t.Fatalf("read stdout file: %v", err)
+	}
+
+	return string(written), runErr
+}
+
+func TestApply_JSONOutputAutoApprove(t *testing.T) {
+	change := &recordedChange{op: eleconf.OpUpdate}
+
+	stdout, err := runApply(t, &eleconf.Plan{
+		Changes: []eleconf.ConfigurationChange{change},
+	}, "--output", "json", "--auto-approve")
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	if !change.executed {
+		t.Error("expected the change to be applied")
+	}
+
+	var report eleconf.PlanReport
+
+	err = json.Unmarshal([]byte(stdout), &report)
+	if err != nil {
+		t.Fatalf("expected stdout to only have the JSON plan: %v\n%s",
+			err, stdout)
+	}
+
+	if len(report.Changes) != 1 {
+		t.Errorf("expected one change in the JSON plan, got %d",
+			len(report.Changes))
+	}
+}
+
+func TestApply_JSONOutputNonDestructive(t *testing.T) {
+	change := &recordedChange{op: eleconf.OpRemove}
+
+	_, err := runApply(t, &eleconf.Plan{
+		Changes: []eleconf.ConfigurationChange{change},
+	}, "--output", "json", "--auto-approve=non-destructive")
+	if err == nil {
+		t.Fatal("expected destructive changes to fail the apply")
+	}
+
+	if change.executed {
+		t.Error("expected the destructive change not to be applied")
+	}
+}
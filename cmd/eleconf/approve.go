@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+// autoApprove is the mode of the auto-approve flag.
+type autoApprove string
+
+const (
+	approveNone           autoApprove = ""
+	approveAll            autoApprove = "all"
+	approveNonDestructive autoApprove = "non-destructive"
+)
+
+// autoApproveValue is a flag value that can be used as a boolean flag,
+// "--auto-approve", or with a mode, "--auto-approve=non-destructive".
+type autoApproveValue struct {
+	mode autoApprove
+}
+
+func (v *autoApproveValue) Set(s string) error {
+	switch s {
+	case "true", string(approveAll):
+		v.mode = approveAll
+	case "false":
+		v.mode = approveNone
+	case string(approveNonDestructive):
+		v.mode = approveNonDestructive
+	default:
+		return fmt.Errorf(
+			"unknown auto-approve mode %q, expected %q", s,
+			approveNonDestructive)
+	}
+
+	return nil
+}
+
+func (v *autoApproveValue) String() string {
+	return string(v.mode)
+}
+
+func (v *autoApproveValue) Get() any {
+	return v.mode
+}
+
+// IsBoolFlag lets the flag be given without a value.
+func (v *autoApproveValue) IsBoolFlag() bool {
+	return true
+}
+
+func autoApproveFlag() cli.Flag {
+	return &cli.GenericFlag{
+		Name:  "auto-approve",
+		Usage: "Apply the changes without asking for confirmation. Use --auto-approve=non-destructive to only proceed if nothing is removed or downgraded, including type configuration settings",
+		Value: &autoApproveValue{},
+	}
+}
+
+func autoApproveMode(cmd *cli.Command) autoApprove {
+	mode, _ := cmd.Value("auto-approve").(autoApprove)
+
+	return mode
+}
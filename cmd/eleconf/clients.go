@@ -13,9 +13,22 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// adminScopes are requested by commands that change the repository.
+// Including doc_read here lets unprivileged client check if the state is
+// clean.
+var adminScopes = []string{
+	"workflow_admin", "schema_admin", "doc_read",
+	"metrics_admin",
+}
+
+// readScopes are requested by commands that only read the repository
+// configuration.
+var readScopes = []string{"doc_read"}
+
 func getClients(
 	ctx context.Context,
 	cmd *cli.Command,
+	scopes []string,
 ) (*eleconf.StaticClients, error) {
 	clientID := cmd.String("client-id")
 	clientSecret := cmd.String("client-secret")
@@ -40,13 +53,6 @@ func getClients(
 
 	var token oauth2.TokenSource
 
-	// Including doc_read here lets unprivileged client check if the state
-	// is clean.
-	scopes := []string{
-		"workflow_admin", "schema_admin", "doc_read",
-		"metrics_admin",
-	}
-
 	if clientSecret != "" {
 		t, err := conf.GetClientAccessToken(
 			ctx, clientID, clientSecret, scopes)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/pmezard/go-difflib/difflib"
+	"github.com/ttab/eleconf"
 	"github.com/urfave/cli/v3"
 )
 
@@ -128,22 +130,41 @@ func checkDir(path string) error {
 	return nil
 }
 
+// listHCLFiles lists the configuration files in dir and its subdirectories,
+// as slash separated paths relative to dir. Hidden directories are skipped.
 func listHCLFiles(dir string) ([]string, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-
 	var names []string
 
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
+	err := filepath.WalkDir(dir, func(
+		path string, d fs.DirEntry, err error,
+	) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !eleconf.IsConfigFile(d.Name()) {
+			return nil
 		}
 
-		if filepath.Ext(e.Name()) == ".hcl" {
-			names = append(names, e.Name())
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
 		}
+
+		names = append(names, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	sort.Strings(names)
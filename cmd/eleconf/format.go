@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/ttab/eleconf"
+	"github.com/urfave/cli/v3"
+)
+
+func formatAction(_ context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+	check := cmd.Bool("check")
+
+	opts := eleconf.FormatOptions{
+		SortLists: cmd.Bool("sort"),
+	}
+
+	names, err := listHCLFiles(dir)
+	if err != nil {
+		return fmt.Errorf("list %q: %w", dir, err)
+	}
+
+	var (
+		unformatted int
+		diags       hcl.Diagnostics
+		files       = make(map[string]*hcl.File)
+	)
+
+	for _, name := range names {
+		// There's no canonical formatting of HCL JSON files, they are
+		// usually generated.
+		if eleconf.IsJSONConfigFile(name) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %q: %w", path, err)
+		}
+
+		formatted, fDiags := eleconf.FormatFile(src, path, opts)
+		if fDiags.HasErrors() {
+			diags = append(diags, fDiags...)
+			files[path] = &hcl.File{Bytes: src}
+
+			continue
+		}
+
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+
+		unformatted++
+
+		fmt.Println(path)
+
+		if check {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat %q: %w", path, err)
+		}
+
+		err = os.WriteFile(path, formatted, info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("write %q: %w", path, err)
+		}
+	}
+
+	if diags.HasErrors() {
+		return &eleconf.ConfigError{
+			Diagnostics: diags,
+			Files:       files,
+		}
+	}
+
+	if check && unformatted > 0 {
+		return fmt.Errorf("%d file(s) are not formatted", unformatted)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ttab/eleconf"
+	"github.com/urfave/cli/v3"
+)
+
+const defaultSchemaRepository = "https://github.com/ttab/revisorschemas.git"
+
+func importAction(ctx context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return fmt.Errorf("create configuration directory: %w", err)
+	}
+
+	existing, err := listHCLFiles(dir)
+	if err != nil {
+		return fmt.Errorf("list %q: %w", dir, err)
+	}
+
+	if len(existing) > 0 && !cmd.Bool("force") {
+		return fmt.Errorf(
+			"%q already contains configuration files, use --force to overwrite",
+			dir)
+	}
+
+	clients, err := getClients(ctx, cmd, readScopes)
+	if err != nil {
+		return fmt.Errorf("get API clients: %w", err)
+	}
+
+	imported, err := eleconf.ImportConfig(
+		ctx, clients, cmd.String("schema-repository"))
+	if err != nil {
+		return fmt.Errorf("import configuration: %w", err)
+	}
+
+	err = imported.Write(dir)
+	if err != nil {
+		return fmt.Errorf("write configuration: %w", err)
+	}
+
+	err = writeLockFile(ctx, imported.Config, nil,
+		dir, eleconf.LockFilePath(dir))
+	if err != nil {
+		return fmt.Errorf("create lock file: %w", err)
+	}
+
+	fmt.Printf("Imported %d document types, %d schema sets and %d metric kinds\n",
+		len(imported.Config.Documents), len(imported.Config.SchemaSets),
+		len(imported.Config.Metric))
+
+	return nil
+}
@@ -0,0 +1,890 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/ttab/eleconf"
+	"github.com/urfave/cli/v3"
+)
+
+func lspAction(ctx context.Context, cmd *cli.Command) error {
+	root, err := filepath.Abs(cmd.String("dir"))
+	if err != nil {
+		return fmt.Errorf("resolve configuration directory: %w", err)
+	}
+
+	s := lspServer{
+		cmd:       cmd,
+		root:      root,
+		out:       os.Stdout,
+		docs:      make(map[string][]byte),
+		published: make(map[string]bool),
+	}
+
+	return s.serve(ctx, os.Stdin)
+}
+
+// lspServer is a language server for configuration files. It speaks
+// JSON-RPC over stdio, and supports document type and workflow step
+// completion, definitions of variant base types, and diagnostics.
+type lspServer struct {
+	cmd  *cli.Command
+	root string
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	// reloadMu serialises configuration reloads.
+	reloadMu sync.Mutex
+
+	mu        sync.Mutex
+	docs      map[string][]byte
+	conf      *eleconf.Config
+	confDiags hcl.Diagnostics
+	files     map[string]*hcl.File
+	docTypes  []string
+	published map[string]bool
+	// reloadTimer debounces reloads on changes, and cancelLoad cancels
+	// the loading of document types for the previous configuration.
+	reloadTimer *time.Timer
+	cancelLoad  context.CancelFunc
+}
+
+// reloadDelay is how long to wait for more changes before validating the
+// configuration.
+const reloadDelay = 300 * time.Millisecond
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	rpcMethodNotFound = -32601
+	rpcInternalError  = -32603
+)
+
+var errExit = errors.New("exit")
+
+func (s *lspServer) serve(ctx context.Context, in io.Reader) error {
+	r := bufio.NewReader(in)
+
+	for {
+		msg, err := readMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read message: %w", err)
+		}
+
+		result, err := s.handle(ctx, msg)
+		if errors.Is(err, errExit) {
+			return nil
+		}
+
+		// Notifications don't get a response.
+		if msg.ID == nil {
+			if err != nil {
+				slog.Error("handle notification",
+					"method", msg.Method, "err", err)
+			}
+
+			continue
+		}
+
+		res := rpcMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+
+		var rErr *rpcError
+
+		switch {
+		case errors.As(err, &rErr):
+			res.Error = rErr
+		case err != nil:
+			res.Error = &rpcError{
+				Code:    rpcInternalError,
+				Message: err.Error(),
+			}
+		case result == nil:
+			res.Result = json.RawMessage("null")
+		}
+
+		err = s.send(res)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func readMessage(r *bufio.Reader) (*rpcMessage, error) {
+	var length int
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid content length: %w", err)
+			}
+		}
+	}
+
+	body := make([]byte, length)
+
+	_, err := io.ReadFull(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg rpcMessage
+
+	err = json.Unmarshal(body, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+func (s *lspServer) send(msg rpcMessage) error {
+	msg.JSONRPC = "2.0"
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	if err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	return nil
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type lspCompletionItem struct {
+	Label    string      `json:"label"`
+	Kind     int         `json:"kind"`
+	TextEdit lspTextEdit `json:"textEdit"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+const (
+	lspSeverityError      = 1
+	lspSeverityWarning    = 2
+	lspCompletionKindEnum = 20
+)
+
+func (s *lspServer) handle(ctx context.Context, msg *rpcMessage) (any, error) {
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(msg.Params)
+	case "initialized":
+		s.reload(ctx, true)
+
+		return nil, nil
+	case "shutdown":
+		return nil, nil
+	case "exit":
+		return nil, errExit
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+
+		err := json.Unmarshal(msg.Params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		s.setDocument(p.TextDocument.URI, []byte(p.TextDocument.Text))
+
+		return nil, nil
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+
+		err := json.Unmarshal(msg.Params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		// We use full document sync, the last change has the
+		// complete text.
+		text := p.ContentChanges[len(p.ContentChanges)-1].Text
+
+		s.setDocument(p.TextDocument.URI, []byte(text))
+		s.scheduleReload(ctx)
+
+		return nil, nil
+	case "textDocument/didSave":
+		s.reload(ctx, true)
+
+		return nil, nil
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+
+		err := json.Unmarshal(msg.Params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		delete(s.docs, p.TextDocument.URI)
+		s.mu.Unlock()
+
+		s.publish(p.TextDocument.URI)
+		s.scheduleReload(ctx)
+
+		return nil, nil
+	case "textDocument/completion":
+		var p lspTextDocumentPosition
+
+		err := json.Unmarshal(msg.Params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.complete(p), nil
+	case "textDocument/definition":
+		var p lspTextDocumentPosition
+
+		err := json.Unmarshal(msg.Params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.definition(p), nil
+	default:
+		if msg.ID == nil {
+			return nil, nil
+		}
+
+		return nil, &rpcError{
+			Code:    rpcMethodNotFound,
+			Message: "method not supported: " + msg.Method,
+		}
+	}
+}
+
+func (s *lspServer) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		RootURI string `json:"rootUri"`
+	}
+
+	err := json.Unmarshal(params, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.RootURI != "" && !s.cmd.IsSet("dir") {
+		s.root = uriToPath(p.RootURI)
+	}
+
+	return map[string]any{
+		"capabilities": map[string]any{
+			// Full document sync.
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1,
+				"save":      true,
+			},
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{`"`, "/"},
+			},
+			"definitionProvider": true,
+		},
+		"serverInfo": map[string]any{
+			"name":    appName,
+			"version": version,
+		},
+	}, nil
+}
+
+func (s *lspServer) setDocument(uri string, text []byte) {
+	s.mu.Lock()
+	s.docs[uri] = text
+	s.mu.Unlock()
+
+	s.publish(uri)
+}
+
+// scheduleReload reloads the configuration when there have been no changes
+// for reloadDelay.
+func (s *lspServer) scheduleReload(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reloadTimer != nil {
+		s.reloadTimer.Stop()
+	}
+
+	s.reloadTimer = time.AfterFunc(reloadDelay, func() {
+		s.reload(ctx, false)
+	})
+}
+
+// reload reads the configuration, with the contents of the open files in
+// place of the files on disk, validates it, and publishes the diagnostics.
+// If loadTypes is set the document types of the locked schemas are loaded
+// in the background.
+func (s *lspServer) reload(ctx context.Context, loadTypes bool) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	var (
+		diags hcl.Diagnostics
+		files map[string]*hcl.File
+	)
+
+	conf, err := s.readConfig()
+
+	var confErr *eleconf.ConfigError
+
+	switch {
+	case errors.As(err, &confErr):
+		diags = confErr.Diagnostics
+		files = confErr.Files
+	case err != nil:
+		slog.Error("read configuration", "err", err)
+	default:
+		diags = eleconf.HCLDiagnostics(eleconf.Validate(conf))
+		files = conf.Files()
+	}
+
+	s.mu.Lock()
+
+	if conf != nil {
+		s.conf = conf
+	}
+
+	s.confDiags = diags
+	s.files = files
+
+	uris := make(map[string]bool)
+
+	for uri := range s.published {
+		uris[uri] = true
+	}
+
+	for uri := range s.docs {
+		uris[uri] = true
+	}
+
+	for _, d := range diags {
+		if d.Subject != nil {
+			uris[pathToURI(d.Subject.Filename)] = true
+		}
+	}
+
+	var loadCtx context.Context
+
+	if loadTypes && conf != nil {
+		if s.cancelLoad != nil {
+			s.cancelLoad()
+		}
+
+		loadCtx, s.cancelLoad = context.WithCancel(ctx)
+	}
+
+	s.mu.Unlock()
+
+	for uri := range uris {
+		s.publish(uri)
+	}
+
+	if loadCtx != nil {
+		go s.loadDocumentTypes(loadCtx, conf)
+	}
+}
+
+// readConfig reads the configuration with the open files as sources.
+func (s *lspServer) readConfig() (*eleconf.Config, error) {
+	opts, err := configOptions(s.cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+
+	opts.Sources = make(map[string][]byte, len(s.docs))
+
+	for uri, text := range s.docs {
+		opts.Sources[uriToPath(uri)] = text
+	}
+
+	s.mu.Unlock()
+
+	conf, err := eleconf.ReadConfig(s.root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("read configuration: %w", err)
+	}
+
+	return conf, nil
+}
+
+func (s *lspServer) loadDocumentTypes(
+	ctx context.Context, conf *eleconf.Config,
+) {
+	lockPath, err := eleconf.EnvironmentLockFilePath(
+		s.root, s.cmd.String("env"))
+	if err != nil {
+		slog.Error("find lock file", "err", err)
+
+		return
+	}
+
+	lock, err := eleconf.LoadLockFile(lockPath)
+	if err != nil {
+		slog.Error("load lock file", "err", err)
+
+		return
+	}
+
+	cache, err := eleconf.NewSchemaCache()
+	if err != nil {
+		slog.Error("open schema cache", "err", err)
+
+		return
+	}
+
+	var schemas []eleconf.LoadedSchema
+
+	for _, set := range conf.SchemaSets {
+		loaded, err := eleconf.LoadSchemaSetCached(ctx, set, lock, cache)
+		if err != nil {
+			slog.Error("load schema set",
+				"name", set.Name, "err", err)
+
+			return
+		}
+
+		schemas = append(schemas, loaded...)
+	}
+
+	declared, err := eleconf.DeclaredDocumentTypes(schemas)
+	if err != nil {
+		slog.Error("read document types", "err", err)
+
+		return
+	}
+
+	var types []string
+
+	for t := range declared {
+		types = append(types, t)
+	}
+
+	slices.Sort(types)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A newer configuration has replaced this one.
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.docTypes = types
+}
+
+// publish sends the diagnostics for a file. Open files with syntax errors
+// get the syntax errors, other files get the diagnostics from the last
+// configuration load.
+func (s *lspServer) publish(uri string) {
+	path := uriToPath(uri)
+
+	s.mu.Lock()
+
+	text, open := s.docs[uri]
+
+	var diags hcl.Diagnostics
+
+	switch {
+	case open && eleconf.IsJSONConfigFile(path):
+		_, diags = hcljson.Parse(text, path)
+	case open:
+		_, diags = hclsyntax.ParseConfig(text, path, hcl.InitialPos)
+	}
+
+	if !diags.HasErrors() {
+		diags = nil
+
+		for _, d := range s.confDiags {
+			if d.Subject != nil && d.Subject.Filename == path {
+				diags = append(diags, d)
+			}
+		}
+	}
+
+	s.published[uri] = len(diags) > 0
+
+	if !open {
+		text = s.fileText(path)
+	}
+
+	s.mu.Unlock()
+
+	list := make([]lspDiagnostic, 0, len(diags))
+
+	for _, d := range diags {
+		severity := lspSeverityError
+		if d.Severity == hcl.DiagWarning {
+			severity = lspSeverityWarning
+		}
+
+		message := d.Summary
+		if d.Detail != "" {
+			message += ": " + d.Detail
+		}
+
+		list = append(list, lspDiagnostic{
+			Range:    hclToLSPRange(text, *d.Subject),
+			Severity: severity,
+			Source:   appName,
+			Message:  message,
+		})
+	}
+
+	params, err := json.Marshal(map[string]any{
+		"uri":         uri,
+		"diagnostics": list,
+	})
+	if err != nil {
+		slog.Error("marshal diagnostics", "err", err)
+
+		return
+	}
+
+	err = s.send(rpcMessage{
+		Method: "textDocument/publishDiagnostics",
+		Params: params,
+	})
+	if err != nil {
+		slog.Error("publish diagnostics", "err", err)
+	}
+}
+
+func (s *lspServer) complete(p lspTextDocumentPosition) []lspCompletionItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	offset := byteOffset(text, p.Position)
+	c := eleconf.AnalyzeCursor(text, offset)
+
+	var candidates []string
+
+	switch c.Kind {
+	case eleconf.CursorDocumentType:
+		candidates = s.docTypes
+	case eleconf.CursorWorkflowStep:
+		candidates = slices.Clone(c.Statuses)
+
+		// Statuses can come from profiles, use the resolved statuses
+		// from the last loaded configuration as well.
+		for _, st := range s.resolvedStatuses(c.BlockType, c.BlockLabel) {
+			if !slices.Contains(candidates, st) {
+				candidates = append(candidates, st)
+			}
+		}
+	default:
+		return nil
+	}
+
+	replace := lspRange{
+		Start: lspPositionAt(text, c.Start),
+		End:   lspPositionAt(text, c.End),
+	}
+
+	items := []lspCompletionItem{}
+
+	for _, value := range candidates {
+		if !strings.HasPrefix(value, c.Prefix) {
+			continue
+		}
+
+		items = append(items, lspCompletionItem{
+			Label: value,
+			Kind:  lspCompletionKindEnum,
+			TextEdit: lspTextEdit{
+				Range:   replace,
+				NewText: value,
+			},
+		})
+	}
+
+	return items
+}
+
+func (s *lspServer) resolvedStatuses(blockType, label string) []string {
+	if s.conf == nil {
+		return nil
+	}
+
+	switch blockType {
+	case "document":
+		for _, d := range s.conf.Documents {
+			if d.Type == label {
+				return d.Statuses
+			}
+		}
+	case "workflow_profile":
+		for _, p := range s.conf.WorkflowProfiles {
+			if p.Name == label {
+				return p.Statuses
+			}
+		}
+	}
+
+	return nil
+}
+
+// definition resolves the base type document of a variant.
+func (s *lspServer) definition(p lspTextDocumentPosition) []lspLocation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, ok := s.docs[p.TextDocument.URI]
+	if !ok || s.conf == nil {
+		return nil
+	}
+
+	c := eleconf.AnalyzeCursor(text, byteOffset(text, p.Position))
+	if c.Kind != eleconf.CursorDocumentType {
+		return nil
+	}
+
+	base, variant := eleconf.ParseDocumentType(c.Value)
+	if variant == "" {
+		return nil
+	}
+
+	for _, d := range s.conf.Documents {
+		if d.Type != base {
+			continue
+		}
+
+		return []lspLocation{{
+			URI: pathToURI(d.DeclRange.Filename),
+			Range: hclToLSPRange(
+				s.fileText(d.DeclRange.Filename), d.DeclRange),
+		}}
+	}
+
+	return nil
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		path = abs
+	}
+
+	u := url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(path),
+	}
+
+	return u.String()
+}
+
+// fileText returns the contents of a configuration file, preferring the text
+// of an open document. Returns nil if the file hasn't been read. Must be
+// called with s.mu held.
+func (s *lspServer) fileText(path string) []byte {
+	text, ok := s.docs[pathToURI(path)]
+	if ok {
+		return text
+	}
+
+	f, ok := s.files[path]
+	if ok {
+		return f.Bytes
+	}
+
+	return nil
+}
+
+// hclToLSPRange converts a range to LSP positions, which count characters in
+// UTF-16 code units. HCL columns count characters, so the byte offsets of
+// the range are used when the text of the file is available.
+func hclToLSPRange(text []byte, r hcl.Range) lspRange {
+	if text != nil {
+		return lspRange{
+			Start: lspPositionAt(text, r.Start.Byte),
+			End:   lspPositionAt(text, r.End.Byte),
+		}
+	}
+
+	return lspRange{
+		Start: lspPosition{
+			Line:      max(r.Start.Line-1, 0),
+			Character: max(r.Start.Column-1, 0),
+		},
+		End: lspPosition{
+			Line:      max(r.End.Line-1, 0),
+			Character: max(r.End.Column-1, 0),
+		},
+	}
+}
+
+// byteOffset converts a position with UTF-16 character offsets to a byte
+// offset in text.
+func byteOffset(text []byte, pos lspPosition) int {
+	offset := 0
+
+	for range pos.Line {
+		i := bytes.IndexByte(text[offset:], '\n')
+		if i == -1 {
+			return len(text)
+		}
+
+		offset += i + 1
+	}
+
+	for units := 0; units < pos.Character && offset < len(text); {
+		r, size := utf8.DecodeRune(text[offset:])
+		if r == '\n' {
+			break
+		}
+
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+
+	return offset
+}
+
+// lspPositionAt converts a byte offset in text to a position with UTF-16
+// character offsets.
+func lspPositionAt(text []byte, offset int) lspPosition {
+	var pos lspPosition
+
+	for i := 0; i < offset && i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character += utf16.RuneLen(r)
+		}
+
+		i += size
+	}
+
+	return pos
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/fatih/color"
@@ -42,21 +47,10 @@ func main() {
 		Name:        "update",
 		Description: "Refresh schema and exemplar lockfile",
 		Action:      updateAction,
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:      "dir",
-				Usage:     "Configuration directory",
-				Value:     ".",
-				TakesFile: true,
-			},
-		},
+		Flags:       configFlags(),
 	}
 
 	authFlags := []cli.Flag{
-		&cli.StringFlag{
-			Name:    "env",
-			Sources: cli.EnvVars("ENV"),
-		},
 		&cli.StringFlag{
 			Name:    "client-id",
 			Usage:   "Client ID",
@@ -71,40 +65,129 @@ func main() {
 
 	applyCmd := cli.Command{
 		Name:        "apply",
-		Description: "Applies elephant configuration",
+		Description: "Applies elephant configuration, or a saved plan file",
+		ArgsUsage:   "[<plan-file>]",
 		Action:      applyAction,
-		Flags: append([]cli.Flag{
+		Flags: append(append(rootFlags(), authFlags...),
+			outputFlag(), autoApproveFlag()),
+	}
+
+	planCmd := cli.Command{
+		Name:        "plan",
+		Description: "Show the changes that apply would make, and optionally save them to a plan file",
+		Action:      planAction,
+		Flags: append(append(rootFlags(), authFlags...), outputFlag(),
 			&cli.StringFlag{
-				Name:      "dir",
-				Usage:     "Configuration directory",
-				Value:     ".",
+				Name:      "out",
+				Usage:     "Save the plan to a file that can be applied with apply <plan-file>",
 				TakesFile: true,
-			},
-		}, authFlags...),
+			}),
+	}
+
+	checkCmd := cli.Command{
+		Name:        "check",
+		Description: "Check if the repository is in sync with the configuration. Exits with 0 when in sync, 2 when changes are needed, and 1 on errors",
+		Action:      checkAction,
+		Flags:       append(append(rootFlags(), authFlags...), outputFlag()),
 	}
 
 	generationPendingCmd := cli.Command{
 		Name:        "pending",
 		Description: "Register a pending schema generation without applying other configuration",
 		Action:      generationPendingAction,
+		Flags: append(append(rootFlags(), authFlags...),
+			outputFlag(), autoApproveFlag()),
+	}
+
+	generationCmd := cli.Command{
+		Name:        "generation",
+		Description: "Schema generation management",
+		Commands: []*cli.Command{
+			&generationPendingCmd,
+		},
+	}
+
+	validateCmd := cli.Command{
+		Name:        "validate",
+		Description: "Check the configuration for errors without contacting the repository",
+		Action:      validateAction,
+		Flags:       configFlags(),
+	}
+
+	showCmd := cli.Command{
+		Name:        "show",
+		Description: "Print the merged configuration with defaults applied, and the documents added for the types in the locked schemas",
+		Action:      showAction,
+		Flags: append(configFlags(), &cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format, json or hcl",
+			Value: "json",
+		}),
+	}
+
+	schemaCmd := cli.Command{
+		Name:        "schema",
+		Description: "Print a JSON Schema that describes the configuration files",
+		Action:      schemaAction,
+	}
+
+	importCmd := cli.Command{
+		Name:        "import",
+		Description: "Generate configuration files and a lock file from a live repository",
+		Action:      importAction,
 		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:      "dir",
-				Usage:     "Configuration directory",
+				Usage:     "Directory to write the configuration to",
 				Value:     ".",
 				TakesFile: true,
 			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment to import from",
+				Sources: cli.EnvVars("ENV"),
+			},
+			&cli.StringFlag{
+				Name:  "schema-repository",
+				Usage: "Git repository that the imported schema sets are loaded from",
+				Value: defaultSchemaRepository,
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Overwrite existing configuration files",
+			},
 		}, authFlags...),
 	}
 
-	generationCmd := cli.Command{
-		Name:        "generation",
-		Description: "Schema generation management",
-		Commands: []*cli.Command{
-			&generationPendingCmd,
+	fmtCmd := cli.Command{
+		Name:        "fmt",
+		Description: "Rewrite configuration files in the canonical format",
+		Action:      formatAction,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "dir",
+				Usage:     "Configuration directory",
+				Value:     ".",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "Only list unformatted files, and fail if there are any",
+			},
+			&cli.BoolFlag{
+				Name:  "sort",
+				Usage: "Sort lists where the order doesn't matter, like statuses",
+			},
 		},
 	}
 
+	lspCmd := cli.Command{
+		Name:        "lsp",
+		Description: "Run a language server for configuration files over stdio",
+		Action:      lspAction,
+		Flags:       configFlags(),
+	}
+
 	diffCmd := cli.Command{
 		Name:        "diff",
 		Description: "Compare HCL configuration files between two directories",
@@ -118,32 +201,271 @@ func main() {
 		Commands: []*cli.Command{
 			&versionCmd,
 			&updateCmd,
+			&planCmd,
 			&applyCmd,
+			&checkCmd,
 			&generationCmd,
+			&validateCmd,
+			&showCmd,
+			&schemaCmd,
+			&importCmd,
+			&fmtCmd,
 			&diffCmd,
+			&lspCmd,
 			clitools.ConfigureCliCommands("eleconf", clitools.DefaultApplicationID),
 		},
 	}
 
 	if err := app.Run(context.Background(), os.Args); err != nil {
+		var confErr *eleconf.ConfigError
+
+		if errors.As(err, &confErr) {
+			_ = confErr.WriteText(os.Stderr, 80, !color.NoColor)
+
+			println("error: invalid configuration")
+			os.Exit(1)
+		}
+
 		println("error: ", err.Error())
 		os.Exit(1)
 	}
 }
 
+// configFlags returns the flags used by commands that read the configuration.
+func configFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:      "dir",
+			Usage:     "Configuration directory",
+			Value:     ".",
+			TakesFile: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "var",
+			Usage: "Set a configuration variable, as name=value",
+		},
+		&cli.StringFlag{
+			Name:    "env",
+			Usage:   "Environment, selects the configuration overlay and lock file",
+			Sources: cli.EnvVars("ENV"),
+		},
+		&cli.StringFlag{
+			Name:      "include-root",
+			Usage:     "Directory that included files must be in, defaults to the configuration directory, or the common parent of several roots",
+			TakesFile: true,
+		},
+	}
+}
+
+// rootFlags returns the configuration flags for commands that can combine
+// several configuration roots, with a repeatable "dir" flag.
+func rootFlags() []cli.Flag {
+	flags := configFlags()
+
+	flags[0] = &cli.StringSliceFlag{
+		Name:      "dir",
+		Usage:     "Configuration directory, repeat to combine several roots",
+		Value:     []string{"."},
+		TakesFile: true,
+	}
+
+	return flags
+}
+
+// outputFlag returns the flag that selects the output format of commands
+// that display changes.
+func outputFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "output",
+		Usage: "Output format, text or json. With json, stdout only has the changes",
+		Value: "text",
+		Validator: func(v string) error {
+			if v != "text" && v != "json" {
+				return fmt.Errorf("unknown output format %q", v)
+			}
+
+			return nil
+		},
+	}
+}
+
+// varEnvPrefix is the prefix for environment variables that set
+// configuration variables, f.ex. ELECONF_VAR_region=eu.
+const varEnvPrefix = "ELECONF_VAR_"
+
+// configOptions returns the configuration options given by the flags and
+// ELECONF_VAR_* environment variables.
+func configOptions(cmd *cli.Command) (eleconf.ConfigOptions, error) {
+	vars := make(map[string]string)
+	envVars := make(map[string]string)
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+
+		name, ok := strings.CutPrefix(name, varEnvPrefix)
+		if !ok || name == "" {
+			continue
+		}
+
+		envVars[name] = value
+	}
+
+	for _, v := range cmd.StringSlice("var") {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return eleconf.ConfigOptions{}, fmt.Errorf(
+				"invalid variable %q, expected name=value", v)
+		}
+
+		vars[name] = value
+	}
+
+	return eleconf.ConfigOptions{
+		Variables:    vars,
+		EnvVariables: envVars,
+		IncludeRoot:  cmd.String("include-root"),
+		Environment:  cmd.String("env"),
+	}, nil
+}
+
+func readConfig(cmd *cli.Command, dir string) (*eleconf.Config, error) {
+	opts, err := configOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := eleconf.ReadConfig(dir, opts)
+	if err != nil {
+		return nil, fmt.Errorf("read configuration: %w", err)
+	}
+
+	return conf, nil
+}
+
+// readRoots reads the configuration roots. The same variable values are
+// given to all roots, and a root ignores the variables it doesn't declare,
+// but every variable must be declared by at least one root.
+func readRoots(cmd *cli.Command, dirs []string) ([]*eleconf.Config, error) {
+	if len(dirs) == 1 {
+		conf, err := readConfig(cmd, dirs[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return []*eleconf.Config{conf}, nil
+	}
+
+	opts, err := configOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.IgnoreUndeclared = true
+
+	if opts.IncludeRoot == "" {
+		root, err := commonParent(dirs)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.IncludeRoot = root
+	}
+
+	roots := make([]*eleconf.Config, len(dirs))
+	declared := make(map[string]bool)
+
+	for i, dir := range dirs {
+		conf, err := eleconf.ReadConfig(dir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("read configuration in %q: %w",
+				dir, err)
+		}
+
+		for _, name := range conf.DeclaredVariables() {
+			declared[name] = true
+		}
+
+		roots[i] = conf
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(opts.Variables)) {
+		if !declared[name] {
+			return nil, fmt.Errorf(
+				"a value was provided for the variable %q, but it isn't declared in any of the configuration roots %q",
+				name, dirs)
+		}
+	}
+
+	return roots, nil
+}
+
+// commonParent returns the closest directory that contains all of the
+// given directories.
+func commonParent(dirs []string) (string, error) {
+	var parent string
+
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("resolve path %q: %w", dir, err)
+		}
+
+		if parent == "" {
+			parent = abs
+
+			continue
+		}
+
+		for {
+			rel, err := filepath.Rel(parent, abs)
+			if err == nil && rel != ".." &&
+				!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+
+			up := filepath.Dir(parent)
+			if up == parent {
+				return "", fmt.Errorf(
+					"the configuration roots %q have no common parent",
+					dirs)
+			}
+
+			parent = up
+		}
+	}
+
+	return parent, nil
+}
+
 func updateAction(ctx context.Context, cmd *cli.Command) error {
 	dir := cmd.String("dir")
 
-	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	conf, err := readConfig(cmd, dir)
+	if err != nil {
+		return err
+	}
+
+	lockPath, err := eleconf.EnvironmentLockFilePath(dir, cmd.String("env"))
 	if err != nil {
-		return fmt.Errorf("read configuration: %w", err)
+		return err
 	}
 
-	lock, err := eleconf.LoadLockFile(eleconf.LockFilePath(dir))
+	lock, err := eleconf.LoadLockFile(lockPath)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("load lock file: %w", err)
 	}
 
+	return writeLockFile(ctx, conf, lock, dir, lockPath)
+}
+
+// writeLockFile loads the schema sets and exemplars and writes a new lock
+// file. Schemas are checked against the current lock file, if any.
+func writeLockFile(
+	ctx context.Context,
+	conf *eleconf.Config,
+	lock *eleconf.SchemaLockfile,
+	dir string, lockPath string,
+) error {
 	var schemas []eleconf.LoadedSchema
 
 	for _, set := range conf.SchemaSets {
@@ -163,7 +485,7 @@ func updateAction(ctx context.Context, cmd *cli.Command) error {
 
 	lock = eleconf.NewSchemaLockFile(schemas, eleconf.ExemplarLocks(exemplars))
 
-	err = lock.Save(eleconf.LockFilePath(dir))
+	err = lock.Save(lockPath)
 	if err != nil {
 		return fmt.Errorf("save lock file: %w", err)
 	}
@@ -171,20 +493,111 @@ func updateAction(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+func validateAction(_ context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+
+	conf, err := readConfig(cmd, dir)
+	if err != nil {
+		return err
+	}
+
+	diags := eleconf.Validate(conf)
+
+	err = eleconf.WriteDiagnostics(os.Stdout, conf.Files(),
+		eleconf.HCLDiagnostics(diags), 80, !color.NoColor)
+	if err != nil {
+		return err
+	}
+
+	var errCount int
+
+	for _, d := range diags {
+		if d.Severity == eleconf.DiagnosticError {
+			errCount++
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("configuration has %d error(s)", errCount)
+	}
+
+	println("Configuration is valid")
+
+	return nil
+}
+
+// loadSchemasAndExemplars reads the configuration roots and combines them,
+// loading the schemas and exemplars of the root that manages schemas.
 func loadSchemasAndExemplars(
-	ctx context.Context, dir string,
+	ctx context.Context, cmd *cli.Command, dirs []string,
 ) (*eleconf.Config, []eleconf.LoadedSchema, []eleconf.LoadedExemplar, error) {
-	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	roots, err := readRoots(cmd, dirs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		schemas   []eleconf.LoadedSchema
+		exemplars []eleconf.LoadedExemplar
+	)
+
+	for i, dir := range dirs {
+		if !roots[i].Manage.ManagesSchemas() {
+			continue
+		}
+
+		s, e, err := loadRootSchemas(ctx, cmd, dir, roots[i])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		schemas = append(schemas, s...)
+		exemplars = append(exemplars, e...)
+	}
+
+	declared, err := eleconf.DeclaredDocumentTypes(schemas)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf(
+			"read declared document types: %w", err)
+	}
+
+	for _, root := range roots {
+		diags := root.AddSchemaDocuments(declared)
+		if diags.HasErrors() {
+			return nil, nil, nil, &eleconf.ConfigError{
+				Diagnostics: diags,
+				Files:       root.Files(),
+			}
+		}
+	}
+
+	conf, diags := eleconf.CombineConfigs(roots)
+	if diags.HasErrors() {
+		return nil, nil, nil, &eleconf.ConfigError{
+			Diagnostics: diags,
+			Files:       conf.Files(),
+		}
+	}
+
+	return conf, schemas, exemplars, nil
+}
+
+// loadRootSchemas loads the locked schemas and the exemplars of a
+// configuration root.
+func loadRootSchemas(
+	ctx context.Context, cmd *cli.Command, dir string, conf *eleconf.Config,
+) ([]eleconf.LoadedSchema, []eleconf.LoadedExemplar, error) {
+	lockPath, err := eleconf.EnvironmentLockFilePath(dir, cmd.String("env"))
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("read configuration: %w", err)
+		return nil, nil, err
 	}
 
-	lock, err := eleconf.LoadLockFile(eleconf.LockFilePath(dir))
+	lock, err := eleconf.LoadLockFile(lockPath)
 	if errors.Is(err, os.ErrNotExist) {
-		return nil, nil, nil, errors.New(
-			"missing lock file, run eleconf update")
+		return nil, nil, fmt.Errorf(
+			"missing lock file in %q, run eleconf update", dir)
 	} else if err != nil {
-		return nil, nil, nil, fmt.Errorf("load lock file: %w", err)
+		return nil, nil, fmt.Errorf("load lock file: %w", err)
 	}
 
 	var schemas []eleconf.LoadedSchema
@@ -192,7 +605,7 @@ func loadSchemasAndExemplars(
 	for _, set := range conf.SchemaSets {
 		loaded, err := eleconf.LoadSchemaSet(ctx, set, lock, false)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("load schema set %q: %w",
+			return nil, nil, fmt.Errorf("load schema set %q: %w",
 				set.Name, err)
 		}
 
@@ -201,48 +614,218 @@ func loadSchemasAndExemplars(
 
 	exemplars, err := eleconf.LoadExemplars(dir)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("load exemplars: %w", err)
+		return nil, nil, fmt.Errorf("load exemplars: %w", err)
 	}
 
 	err = lock.CheckExemplars(exemplars)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, err
 	}
 
-	return conf, schemas, exemplars, nil
+	return schemas, exemplars, nil
 }
 
 func applyAction(ctx context.Context, cmd *cli.Command) error {
-	dir := cmd.String("dir")
+	if cmd.Args().Present() {
+		return applyPlanFileAction(ctx, cmd, cmd.Args().First())
+	}
 
-	conf, schemas, exemplars, err := loadSchemasAndExemplars(ctx, dir)
+	conf, schemas, exemplars, err := loadSchemasAndExemplars(
+		ctx, cmd, cmd.StringSlice("dir"))
 	if err != nil {
 		return err
 	}
 
-	clients, err := getClients(ctx, cmd)
+	clients, err := getClients(ctx, cmd, adminScopes)
 	if err != nil {
 		return fmt.Errorf("get API clients: %w", err)
 	}
 
-	changes, err := eleconf.GetChanges(ctx, clients, conf, schemas,
+	plan, err := eleconf.PlanChanges(ctx, clients, conf, schemas,
 		exemplars, repository.SchemaActivation_ACTIVATION_ACTIVE)
 	if err != nil {
 		return fmt.Errorf("get changes: %w", err)
 	}
 
-	return displayAndApplyChanges(ctx, clients, changes)
+	return displayAndApplyChanges(ctx, cmd, clients, plan)
 }
 
-func generationPendingAction(ctx context.Context, cmd *cli.Command) error {
-	dir := cmd.String("dir")
+func planAction(ctx context.Context, cmd *cli.Command) error {
+	conf, schemas, exemplars, err := loadSchemasAndExemplars(
+		ctx, cmd, cmd.StringSlice("dir"))
+	if err != nil {
+		return err
+	}
+
+	clients, err := getClients(ctx, cmd, readScopes)
+	if err != nil {
+		return fmt.Errorf("get API clients: %w", err)
+	}
+
+	plan, err := eleconf.PlanChanges(ctx, clients, conf, schemas,
+		exemplars, repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		return fmt.Errorf("get changes: %w", err)
+	}
 
-	conf, schemas, exemplars, err := loadSchemasAndExemplars(ctx, dir)
+	asJSON := cmd.String("output") == "json"
+
+	if asJSON {
+		err := writePlanJSON(plan)
+		if err != nil {
+			return err
+		}
+	} else {
+		displayPlan(plan)
+
+		println()
+
+		if len(plan.Changes) == 0 {
+			println("No changes needed")
+		}
+	}
+
+	out := cmd.String("out")
+	if out == "" {
+		return nil
+	}
+
+	pf, err := eleconf.NewPlanFile(
+		ctx, clients, conf, plan, schemas, exemplars)
+	if err != nil {
+		return fmt.Errorf("create plan file: %w", err)
+	}
+
+	pf.Environment = cmd.String("env")
+
+	err = pf.Save(out)
+	if err != nil {
+		return fmt.Errorf("save plan file: %w", err)
+	}
+
+	if !asJSON {
+		fmt.Printf("Plan saved to %s, apply it with: eleconf apply %s\n",
+			out, out)
+	}
+
+	return nil
+}
+
+// driftExitCode is the exit code of the check command when the repository
+// isn't in sync with the configuration.
+const driftExitCode = 2
+
+// checkAction computes the changes using read-only access, and exits with
+// driftExitCode if any are needed. Changes outside of the managed scope
+// don't count.
+func checkAction(ctx context.Context, cmd *cli.Command) error {
+	conf, schemas, exemplars, err := loadSchemasAndExemplars(
+		ctx, cmd, cmd.StringSlice("dir"))
 	if err != nil {
 		return err
 	}
 
-	clients, err := getClients(ctx, cmd)
+	clients, err := getClients(ctx, cmd, readScopes)
+	if err != nil {
+		return fmt.Errorf("get API clients: %w", err)
+	}
+
+	plan, err := eleconf.PlanChanges(ctx, clients, conf, schemas,
+		exemplars, repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		return fmt.Errorf("get changes: %w", err)
+	}
+
+	if cmd.String("output") == "json" {
+		err := writePlanJSON(plan)
+		if err != nil {
+			return err
+		}
+	} else {
+		displayPlan(plan)
+		println()
+	}
+
+	if len(plan.Changes) > 0 {
+		return cli.Exit(fmt.Sprintf(
+			"the repository is out of sync, %d change(s) needed",
+			len(plan.Changes)), driftExitCode)
+	}
+
+	if cmd.String("output") != "json" {
+		println("The repository is in sync with the configuration")
+	}
+
+	return nil
+}
+
+// applyPlanFileAction applies a saved plan without asking for confirmation,
+// as the plan already has been reviewed. With JSON output the plan is
+// printed before it's applied. The plan is only applied if the
+// repository hasn't changed since it was made.
+func applyPlanFileAction(
+	ctx context.Context, cmd *cli.Command, name string,
+) error {
+	pf, err := eleconf.LoadPlanFile(name)
+	if err != nil {
+		return err
+	}
+
+	env := cmd.String("env")
+	if pf.Environment != env {
+		return fmt.Errorf(
+			"the plan was made for the environment %q, not %q",
+			pf.Environment, env)
+	}
+
+	plan, err := pf.Plan()
+	if err != nil {
+		return fmt.Errorf("load plan: %w", err)
+	}
+
+	clients, err := getClients(ctx, cmd, adminScopes)
+	if err != nil {
+		return fmt.Errorf("get API clients: %w", err)
+	}
+
+	err = pf.CheckRemote(ctx, clients)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+
+	if cmd.String("output") == "json" {
+		err := writePlanJSON(plan)
+		if err != nil {
+			return err
+		}
+
+		// Keep stdout for the plan.
+		out = os.Stderr
+	} else {
+		displayPlan(plan)
+
+		println()
+	}
+
+	if len(plan.Changes) == 0 {
+		println("No changes needed")
+
+		return nil
+	}
+
+	return executeChanges(ctx, out, clients, plan.Changes)
+}
+
+func generationPendingAction(ctx context.Context, cmd *cli.Command) error {
+	conf, schemas, exemplars, err := loadSchemasAndExemplars(
+		ctx, cmd, cmd.StringSlice("dir"))
+	if err != nil {
+		return err
+	}
+
+	clients, err := getClients(ctx, cmd, adminScopes)
 	if err != nil {
 		return fmt.Errorf("get API clients: %w", err)
 	}
@@ -254,15 +837,115 @@ func generationPendingAction(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("get generation changes: %w", err)
 	}
 
-	return displayAndApplyChanges(ctx, clients, changes)
+	return displayAndApplyChanges(ctx, cmd, clients,
+		eleconf.NewPlan(conf, changes))
 }
 
+// displayAndApplyChanges shows the changes and applies them once they have
+// been approved. With JSON output stdout only has the changes, everything
+// else, including the confirmation prompt, goes to stderr.
 func displayAndApplyChanges(
 	ctx context.Context,
+	cmd *cli.Command,
 	clients *eleconf.StaticClients,
-	changes []eleconf.ConfigurationChange,
+	plan *eleconf.Plan,
 ) error {
+	out := io.Writer(os.Stdout)
+
+	if cmd.String("output") == "json" {
+		err := writePlanJSON(plan)
+		if err != nil {
+			return err
+		}
+
+		out = os.Stderr
+	} else {
+		displayPlan(plan)
+
+		println()
+	}
+
+	if len(plan.Changes) == 0 {
+		println("No changes needed")
+
+		return nil
+	}
+
+	switch autoApproveMode(cmd) {
+	case approveAll:
+		printAutoApproved(out, plan.Changes)
+	case approveNonDestructive:
+		var destructive []eleconf.ConfigurationChange
+
+		for _, change := range plan.Changes {
+			if eleconf.IsDestructive(change) {
+				destructive = append(destructive, change)
+			}
+		}
+
+		if len(destructive) > 0 {
+			fmt.Fprintln(out, "Destructive changes that need to be approved manually:")
+
+			for _, change := range destructive {
+				fmt.Fprintf(out, "  %s\n", changeSummary(change))
+			}
+
+			println()
+
+			return fmt.Errorf(
+				"%d destructive change(s), not auto-approved",
+				len(destructive))
+		}
+
+		printAutoApproved(out, plan.Changes)
+	default:
+		applyChanges := askForConfirmation(out,
+			"Do you want to apply these changes?")
+		if !applyChanges {
+			return errors.New("aborted by user")
+		}
+	}
+
+	println()
+
+	return executeChanges(ctx, out, clients, plan.Changes)
+}
+
+func printAutoApproved(out io.Writer, changes []eleconf.ConfigurationChange) {
+	fmt.Fprintf(out, "Auto-approved %d change(s):\n", len(changes))
+
 	for _, change := range changes {
+		fmt.Fprintf(out, "  %s\n", changeSummary(change))
+	}
+}
+
+// changeSummary returns the first line of the change description.
+func changeSummary(change eleconf.ConfigurationChange) string {
+	op, info := change.Describe()
+
+	info, _, _ = strings.Cut(info, "\n")
+	info = strings.TrimRight(info, ":")
+
+	return fmt.Sprintf("%s %s", op, info)
+}
+
+// writePlanJSON writes the machine readable form of the plan to stdout.
+func writePlanJSON(plan *eleconf.Plan) error {
+	enc := json.NewEncoder(os.Stdout)
+
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(plan.Report())
+	if err != nil {
+		return fmt.Errorf("encode plan: %w", err)
+	}
+
+	return nil
+}
+
+// displayPlan lists the changes with their warnings, followed by the drift.
+func displayPlan(plan *eleconf.Plan) {
+	for _, change := range plan.Changes {
 		op, info := change.Describe()
 
 		col := color.New()
@@ -292,29 +975,19 @@ func displayAndApplyChanges(
 		}
 	}
 
-	println()
-
-	if len(changes) == 0 {
-		println("No changes needed")
-
-		return nil
-	}
-
-	applyChanges := askForConfirmation(
-		"Do you want to apply these changes?")
-	if !applyChanges {
-		return errors.New("aborted by user")
-	}
-
-	println()
+	displayDrift(plan.Drift)
+}
 
+// executeChanges applies the changes, and lists them on out as they are
+// applied.
+func executeChanges(
+	ctx context.Context,
+	out io.Writer,
+	clients *eleconf.StaticClients,
+	changes []eleconf.ConfigurationChange,
+) error {
 	for _, change := range changes {
-		op, info := change.Describe()
-
-		info, _, _ = strings.Cut(info, "\n")
-		info = strings.TrimRight(info, ":")
-
-		fmt.Println(op, info)
+		fmt.Fprintln(out, changeSummary(change))
 
 		err := change.Execute(ctx, clients)
 		if err != nil {
@@ -328,15 +1001,33 @@ func displayAndApplyChanges(
 	return nil
 }
 
+// displayDrift lists the differences outside of the managed scope, they
+// are never applied.
+func displayDrift(drift []eleconf.ConfigurationChange) {
+	if len(drift) == 0 {
+		return
+	}
+
+	println()
+	fmt.Printf("%d change(s) outside of the managed scope, not applied:\n",
+		len(drift))
+
+	col := color.New(color.FgHiBlack)
+
+	for _, change := range drift {
+		_, _ = col.Printf("  %s\n", changeSummary(change))
+	}
+}
+
 type doomsayer interface {
 	Warnings() []string
 }
 
-func askForConfirmation(s string) bool {
+func askForConfirmation(out io.Writer, s string) bool {
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
-		fmt.Printf("%s [y/n]: ", s)
+		fmt.Fprintf(out, "%s [y/n]: ", s)
 
 		response, err := reader.ReadString('\n')
 		if err != nil {
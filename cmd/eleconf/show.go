@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ttab/eleconf"
+	"github.com/urfave/cli/v3"
+)
+
+func showAction(ctx context.Context, cmd *cli.Command) error {
+	// The locked schemas are loaded like for apply, so that the documents
+	// added by document defaults with schema_types are shown.
+	conf, _, _, err := loadSchemasAndExemplars(
+		ctx, cmd, []string{cmd.String("dir")})
+	if err != nil {
+		return err
+	}
+
+	eff, err := conf.Effective()
+	if err != nil {
+		return fmt.Errorf("resolve configuration: %w", err)
+	}
+
+	switch cmd.String("format") {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(eff)
+		if err != nil {
+			return fmt.Errorf("encode configuration: %w", err)
+		}
+	case "hcl":
+		_, err := os.Stdout.Write(eleconf.EncodeHCL(eff))
+		if err != nil {
+			return fmt.Errorf("write configuration: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", cmd.String("format"))
+	}
+
+	return nil
+}
+
+func schemaAction(_ context.Context, _ *cli.Command) error {
+	enc := json.NewEncoder(os.Stdout)
+
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(eleconf.ConfigJSONSchema())
+	if err != nil {
+		return fmt.Errorf("encode schema: %w", err)
+	}
+
+	return nil
+}
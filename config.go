@@ -4,76 +4,222 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/hashicorp/hcl/v2/hclsimple"
+	"github.com/hashicorp/hcl/v2"
 )
 
 type Config struct {
-	Documents  []DocumentConfig `hcl:"document,block"`
-	SchemaSets []SchemaSet      `hcl:"schema_set,block"`
-	Metric     []MetricKind     `hcl:"metric,block"`
+	// Documents are the configured document types.
+	Documents []DocumentConfig `hcl:"document,block" json:"documents"`
+	// SchemaSets are sets of revisor schemas loaded from a git repository or
+	// URL.
+	SchemaSets []SchemaSet `hcl:"schema_set,block" json:"schema_sets"`
+	// Metric declares the metric kinds.
+	Metric []MetricKind `hcl:"metric,block" json:"metrics"`
+
+	// WorkflowProfiles are statuses and workflows that documents can use
+	// through their profile attribute.
+	WorkflowProfiles []WorkflowProfile `hcl:"workflow_profile,block" json:"workflow_profiles,omitempty"`
+
+	// Manage limits what eleconf manages in the repository, changes outside
+	// of the scope are reported as drift.
+	Manage *ManageScope `hcl:"manage,block" json:"manage,omitempty"`
+
+	files map[string]*hcl.File
+	// defaults are kept for AddSchemaDocuments.
+	defaults []documentDefaults
+	// variables are the names of the declared variables.
+	variables []string
 }
 
+// DeclaredVariables returns the names of the variables declared in the
+// configuration.
+func (c *Config) DeclaredVariables() []string {
+	return c.variables
+}
+
+// Files returns the parsed configuration files, keyed by filename. Used to
+// display diagnostics with source snippets.
+func (c *Config) Files() map[string]*hcl.File {
+	return c.files
+}
+
+// DocumentConfig is the configuration of a document type.
+//
+// Profile names a workflow profile that the statuses and workflow are taken
+// from, unless they are set on the document. AddStatuses and DropStatuses
+// adjust the resulting statuses, dropped statuses are also removed from the
+// workflow steps.
+//
+// EvictNoncurrentAfter is the age after which non-current document versions
+// are evicted, f.ex. "720h" or "30d", see EvictionPeriod.
 type DocumentConfig struct {
-	Type              string             `hcl:"type,label"`
-	MetaDocType       string             `hcl:"meta_doc,optional"`
-	Statuses          []string           `hcl:"statuses,optional"`
-	Workflow          *DocumentWorkflow  `hcl:"workflow,optional"`
-	Attachments       []AttachmentConfig `hcl:"attachment,block"`
-	BoundedCollection bool               `hcl:"bounded_collection,optional"`
-	TimeExpressions   []TimeExpression   `hcl:"time_expression,block"`
-	LabelExpressions  []LabelExpression  `hcl:"label_expression,block"`
-	Variants          []string           `hcl:"variants,optional"`
+	Type      string    `hcl:"type,label" json:"type"`
+	DeclRange hcl.Range `hcl:",def_range" json:"-"`
+	// Body is the body of the document block, used to point diagnostics
+	// at the offending attribute. Nil for documents that weren't declared
+	// in a block.
+	Body hcl.Body `hcl:",body" json:"-"`
+	// MetaDocType is the meta document type for documents of this type.
+	MetaDocType string `hcl:"meta_doc,optional" json:"meta_doc,omitempty"`
+	// Profile is the workflow profile to take statuses and workflow from.
+	Profile string `hcl:"profile,optional" json:"profile,omitempty"`
+	// Statuses are the statuses that documents of this type can have.
+	Statuses []string `hcl:"statuses,optional" json:"statuses,omitempty"`
+	// AddStatuses are statuses to add to the ones from the profile.
+	AddStatuses []string `hcl:"add_statuses,optional" json:"add_statuses,omitempty"`
+	// DropStatuses are statuses to remove from the ones from the profile,
+	// they are also removed from the workflow steps.
+	DropStatuses []string `hcl:"drop_statuses,optional" json:"drop_statuses,omitempty"`
+	// Workflow is the document workflow.
+	Workflow *DocumentWorkflow `hcl:"workflow,optional" json:"workflow,omitempty"`
+	// Attachments are the attachments that documents of this type can have.
+	Attachments []AttachmentConfig `hcl:"attachment,block" json:"attachments,omitempty"`
+	// BoundedCollection marks the type as a bounded collection, which
+	// doesn't grow indefinitely and can be listed in full.
+	BoundedCollection *bool `hcl:"bounded_collection,optional" json:"bounded_collection,omitempty"`
+	// TimeExpressions are expressions that extract the timestamps or dates
+	// that documents of this type cover.
+	TimeExpressions []TimeExpression `hcl:"time_expression,block" json:"time_expressions,omitempty"`
+	// LabelExpressions are expressions that extract labels for documents of
+	// this type.
+	LabelExpressions []LabelExpression `hcl:"label_expression,block" json:"label_expressions,omitempty"`
+	// Variants are variants of the document type, declared as
+	// "<type>#<variant>".
+	Variants []string `hcl:"variants,optional" json:"variants,omitempty"`
+	// EvictNoncurrentAfter is the period after which non-current document
+	// versions are evicted, f.ex. "720h" or "30d". Must be a whole number of
+	// days.
+	EvictNoncurrentAfter string `hcl:"evict_noncurrent_after,optional" json:"evict_noncurrent_after,omitempty"`
+}
+
+// Bounded returns true if the document type is a bounded collection.
+// BoundedCollection is nil if the setting hasn't been given.
+func (dc DocumentConfig) Bounded() bool {
+	return dc.BoundedCollection != nil && *dc.BoundedCollection
+}
+
+// EvictionPeriod parses the EvictNoncurrentAfter setting. Returns zero if no
+// eviction has been configured.
+func (dc DocumentConfig) EvictionPeriod() (time.Duration, error) {
+	if dc.EvictNoncurrentAfter == "" {
+		return 0, nil
+	}
+
+	return parseEvictionPeriod(dc.EvictNoncurrentAfter)
+}
+
+const day = 24 * time.Hour
+
+// parseEvictionPeriod parses a Go duration string, with the addition of a "d"
+// suffix for days. The repository works with days, so the duration must be a
+// positive whole number of days.
+func parseEvictionPeriod(v string) (time.Duration, error) {
+	var (
+		d   time.Duration
+		err error
+	)
+
+	if n, ok := strings.CutSuffix(v, "d"); ok {
+		var days int64
+
+		days, err = strconv.ParseInt(n, 10, 64)
+		d = time.Duration(days) * day
+	} else {
+		d, err = time.ParseDuration(v)
+	}
+
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid duration %q: %w", v, err)
+	case d <= 0:
+		return 0, fmt.Errorf("duration %q must be positive", v)
+	case d%day != 0:
+		return 0, fmt.Errorf(
+			"duration %q must be a whole number of days", v)
+	}
+
+	return d, nil
 }
 
 type TimeExpression struct {
 	// Expression is a newsdoc value extraction expression.
-	Expression string `hcl:"expression"`
+	Expression string `hcl:"expression" json:"expression"`
 	// Layout is the time/date format to use when parsing. Optional,
 	// defaults to RFC3339 or ISO 8601 for values annotated as dates.
-	Layout string `hcl:"layout,optional"`
+	Layout string `hcl:"layout,optional" json:"layout,omitempty"`
 	// Timezone is the timezone the time should be parsed in. Optional, most
 	// timestamps should include timezone information, if they don't,
 	// parsing will fall back to the default timezone that the repository
 	// has been configured with.
-	Timezone string `hcl:"timezone,optional"`
+	Timezone string `hcl:"timezone,optional" json:"timezone,omitempty"`
 }
 
 type LabelExpression struct {
 	// Expression is a newsdoc value extraction expression.
-	Expression string `hcl:"expression"`
+	Expression string `hcl:"expression" json:"expression"`
 	// Template is the template that turns the extracted values into a
 	// label.
-	Template string `hcl:"template"`
+	Template string `hcl:"template" json:"template"`
 }
 
 type DocumentWorkflow struct {
-	StepZero           string   `cty:"step_zero"`
-	Checkpoint         string   `cty:"checkpoint"`
-	NegativeCheckpoint string   `cty:"negative_checkpoint"`
-	Steps              []string `cty:"steps"`
+	// StepZero is the status that a document starts with, and returns to
+	// after the checkpoint.
+	StepZero string `cty:"step_zero" json:"step_zero"`
+	// Checkpoint is the status that completes the workflow, usually
+	// "usable".
+	Checkpoint string `cty:"checkpoint" json:"checkpoint"`
+	// NegativeCheckpoint is the workflow state of a document that has been
+	// withdrawn after the checkpoint.
+	NegativeCheckpoint string `cty:"negative_checkpoint" json:"negative_checkpoint"`
+	// Steps are the statuses that are workflow steps between step zero and
+	// the checkpoint.
+	Steps []string `cty:"steps" json:"steps"`
 }
 
 type SchemaSet struct {
-	Name        string   `hcl:"name,label"`
-	Version     string   `hcl:"version"`
-	URLTemplate string   `hcl:"url_template,optional"`
-	Repository  string   `hcl:"repository,optional"`
-	Schemas     []string `hcl:"schemas"`
+	Name      string    `hcl:"name,label" json:"name"`
+	DeclRange hcl.Range `hcl:",def_range" json:"-"`
+	// Version is the version to load, a tag in the repository.
+	Version string `hcl:"version" json:"version"`
+	// URLTemplate is a Go text template for the schema URLs, with .Name and
+	// .Version available.
+	URLTemplate string `hcl:"url_template,optional" json:"url_template,omitempty"`
+	// Repository is a git repository to load the schemas from.
+	Repository string `hcl:"repository,optional" json:"repository,omitempty"`
+	// Schemas are the names of the schemas in the set.
+	Schemas []string `hcl:"schemas" json:"schemas"`
 }
 
+// AttachmentConfig describes an attachment that documents of a type can
+// have.
+//
+// Attachment configuration is parsed and validated, but not yet applied: the
+// repository API (elephant-api v0.22.1) has no attachment settings in
+// TypeConfiguration, so there is nothing to read back, diff or send in
+// ConfigureType. It will be enforced once the API exposes it.
 type AttachmentConfig struct {
-	Name          string   `hcl:"name,label"`
-	Required      bool     `hcl:"required"`
-	MatchMimetype []string `hcl:"match_mimetype"`
+	Name string `hcl:"name,label" json:"name"`
+	// Required controls whether documents must have the attachment.
+	Required bool `hcl:"required" json:"required"`
+	// MatchMimetype lists the accepted mimetypes of the attachment.
+	MatchMimetype []string `hcl:"match_mimetype" json:"match_mimetype"`
 }
 
 type MetricKind struct {
-	Kind        string            `hcl:"kind,label"`
-	Aggregation MetricAggregation `hcl:"aggregation,optional"`
+	Kind      string    `hcl:"kind,label" json:"kind"`
+	DeclRange hcl.Range `hcl:",def_range" json:"-"`
+	// Aggregation is how metric values are aggregated, "replace" (default)
+	// or "increment".
+	Aggregation MetricAggregation `hcl:"aggregation,optional" json:"aggregation,omitempty"`
 }
 
 type MetricAggregation string
@@ -100,119 +246,83 @@ type SchemaLock struct {
 	Hash    string `json:"hash"`
 }
 
+// lockFileName is the name of the lock file in a configuration directory.
+const lockFileName = "schema.lock.json"
+
 func LockFilePath(dir string) string {
-	return filepath.Join(dir, "schema.lock.json")
+	return filepath.Join(dir, lockFileName)
 }
 
-func ReadConfigFromDirectory(path string) (*Config, error) {
-	entries, err := os.ReadDir(path)
+// EnvironmentLockFilePath returns the lock file path for an environment.
+// Environments have their own lock file in their overlay directory, see
+// EnvironmentLockFileName.
+func EnvironmentLockFilePath(dir string, env string) (string, error) {
+	name, err := EnvironmentLockFileName(os.DirFS(dir), env)
 	if err != nil {
-		return nil, fmt.Errorf("list directory contents: %w", err)
+		return "", err
 	}
 
-	var tutti Config
-
-	for _, entry := range entries {
-		if !strings.HasSuffix(entry.Name(), ".hcl") {
-			continue
-		}
-
-		c, err := parseFile(filepath.Join(path, entry.Name()))
-		if err != nil {
-			return nil, fmt.Errorf(
-				"parse %q: %w", entry.Name(), err)
-		}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
 
-		tutti.SchemaSets = append(tutti.SchemaSets, c.SchemaSets...)
-		tutti.Documents = append(tutti.Documents, c.Documents...)
-		tutti.Metric = append(tutti.Metric, c.Metric...)
+// EnvironmentLockFileName returns the name of the lock file for an
+// environment in a configuration file system. The base lock file is used
+// when no environment is given, or if the configuration has no environment
+// overlays.
+func EnvironmentLockFileName(fsys fs.FS, env string) (string, error) {
+	envDir, err := environmentDir(fsys, ".", env)
+	if err != nil {
+		return "", err
 	}
 
-	seen := make(map[string]bool, len(tutti.Documents))
-
-	for _, doc := range tutti.Documents {
-		if seen[doc.Type] {
-			return nil, fmt.Errorf(
-				"duplicate document type %q", doc.Type)
-		}
-
-		seen[doc.Type] = true
+	if envDir == "" {
+		return lockFileName, nil
 	}
 
-	// Build a map of base type -> declared variants for validation.
-	baseVariants := make(map[string]map[string]bool)
-
-	for _, doc := range tutti.Documents {
-		_, variant := ParseDocumentType(doc.Type)
-		if variant != "" {
-			continue
-		}
-
-		if len(doc.Variants) > 0 {
-			vs := make(map[string]bool, len(doc.Variants))
-			for _, v := range doc.Variants {
-				vs[v] = true
-			}
+	return path.Join(envDir, lockFileName), nil
+}
 
-			baseVariants[doc.Type] = vs
-		}
+// environmentDir returns the overlay directory of an environment in the
+// configuration directory dir, or an empty string if the base configuration
+// should be used. Once a configuration has an "environments/" directory,
+// every environment must have an overlay directory in it.
+func environmentDir(fsys fs.FS, dir string, env string) (string, error) {
+	if env == "" {
+		return "", nil
 	}
 
-	for _, doc := range tutti.Documents {
-		base, variant := ParseDocumentType(doc.Type)
-		if variant == "" {
-			continue
-		}
-
-		if len(doc.Variants) > 0 {
-			return nil, fmt.Errorf(
-				"variant document %q must not define variants",
-				doc.Type)
-		}
+	envsDir := path.Join(dir, "environments")
 
-		if !seen[base] {
-			return nil, fmt.Errorf(
-				"variant document %q: base type %q has not been defined",
-				doc.Type, base)
-		}
-
-		declared := baseVariants[base]
-		if !declared[variant] {
-			return nil, fmt.Errorf(
-				"variant document %q: variant %q has not been declared for %q",
-				doc.Type, variant, base)
-		}
+	_, err := fs.Stat(fsys, envsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("stat environments directory: %w", err)
 	}
 
-	return &tutti, nil
-}
-
-func parseFile(path string) (*Config, error) {
-	var c Config
+	envDir := path.Join(envsDir, env)
 
-	err := hclsimple.DecodeFile(path, nil, &c)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"decode file: %w", err)
-	}
+	info, err := fs.Stat(fsys, envDir)
 
-	for _, m := range c.Metric {
-		switch m.Aggregation {
-		case "", MetricAggregationIncrement, MetricAggregationReplace:
-		default:
-			return nil, fmt.Errorf(
-				"unknown %q metric aggregation %q",
-				m.Kind,
-				m.Aggregation)
-		}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "", fmt.Errorf(
+			"unknown environment %q, there is no %q directory",
+			env, envDir)
+	case err != nil:
+		return "", fmt.Errorf("stat environment directory: %w", err)
+	case !info.IsDir():
+		return "", fmt.Errorf(
+			"unknown environment %q, %q is not a directory",
+			env, envDir)
 	}
 
-	return &c, nil
+	return envDir, nil
 }
 
 type LoadedSchema struct {
-	Lock SchemaLock
-	Data []byte
+	Lock SchemaLock `json:"lock"`
+	Data []byte     `json:"data"`
 }
 
 func LoadSchemaSet(
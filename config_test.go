@@ -1,11 +1,18 @@
 package eleconf_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/ttab/eleconf"
 )
 
@@ -121,3 +128,1172 @@ document "core/article#timeless" {
 		t.Fatalf("expected 2 documents, got %d", len(conf.Documents))
 	}
 }
+
+func TestReadConfigFromDirectory_EvictNoncurrentAfter(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+document "tt/wire" {
+  statuses = ["usable"]
+  evict_noncurrent_after = "720h"
+}
+document "tt/wire-source" {
+  statuses = ["usable"]
+  evict_noncurrent_after = "7d"
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	for _, doc := range conf.Documents {
+		got, err := doc.EvictionPeriod()
+		if err != nil {
+			t.Fatalf("eviction period for %q: %v", doc.Type, err)
+		}
+
+		want := map[string]time.Duration{
+			"tt/wire":        30 * 24 * time.Hour,
+			"tt/wire-source": 7 * 24 * time.Hour,
+		}[doc.Type]
+
+		if got != want {
+			t.Errorf("eviction period for %q = %v, want %v",
+				doc.Type, got, want)
+		}
+	}
+}
+
+func TestReadConfigFromDirectory_InvalidEviction(t *testing.T) {
+	for _, value := range []string{"-24h", "0d", "36h", "soon"} {
+		dir := t.TempDir()
+		writeHCL(t, dir, "test.hcl", `
+document "tt/wire" {
+  statuses = ["usable"]
+  evict_noncurrent_after = "`+value+`"
+}
+`)
+
+		_, err := eleconf.ReadConfigFromDirectory(dir)
+		if err == nil || !strings.Contains(err.Error(), "evict_noncurrent_after") {
+			t.Errorf("expected eviction error for %q, got: %v", value, err)
+		}
+	}
+}
+
+func TestReadConfigFromDirectory_DuplicateLocations(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "a.hcl", `
+document "core/organiser" {
+  statuses = ["usable"]
+}
+`)
+	writeHCL(t, dir, "b.hcl", `
+
+document "core/organiser" {
+  statuses = ["usable"]
+}
+`)
+
+	_, err := eleconf.ReadConfigFromDirectory(dir)
+
+	var confErr *eleconf.ConfigError
+	if !errors.As(err, &confErr) {
+		t.Fatalf("expected a configuration error, got: %v", err)
+	}
+
+	if len(confErr.Diagnostics) != 2 {
+		t.Fatalf("expected a diagnostic for each definition, got: %v",
+			confErr.Diagnostics)
+	}
+
+	diag := confErr.Diagnostics[0]
+
+	if diag.Subject == nil ||
+		filepath.Base(diag.Subject.Filename) != "b.hcl" ||
+		diag.Subject.Start.Line != 3 {
+		t.Errorf("expected the diagnostic subject to be b.hcl line 3, got %v",
+			diag.Subject)
+	}
+
+	if !strings.Contains(diag.Detail, "a.hcl:2") {
+		t.Errorf("expected the detail to point at a.hcl line 2, got %q",
+			diag.Detail)
+	}
+
+	first := confErr.Diagnostics[1]
+
+	if first.Subject == nil ||
+		filepath.Base(first.Subject.Filename) != "a.hcl" ||
+		first.Subject.Start.Line != 2 {
+		t.Errorf("expected the first definition to be reported at a.hcl line 2, got %v",
+			first.Subject)
+	}
+}
+
+func TestReadConfig_VariablesAndLocals(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "locals.hcl", `
+variable "extra_statuses" {
+  default = []
+}
+
+variable "meta_suffix" {
+  default = "+meta"
+}
+
+locals {
+  editorial = ["draft", "done", "usable"]
+  all       = concat(local.editorial, var.extra_statuses)
+}
+`)
+	writeHCL(t, dir, "article.hcl", `
+document "core/article" {
+  meta_doc = "core/article${var.meta_suffix}"
+  statuses = setunion(local.all, ["withheld"])
+}
+`)
+
+	conf, err := eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Variables: map[string]string{
+			"extra_statuses": `["approved"]`,
+		},
+	})
+	if err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	doc := conf.Documents[0]
+
+	if doc.MetaDocType != "core/article+meta" {
+		t.Errorf("unexpected meta doc type %q", doc.MetaDocType)
+	}
+
+	got := slices.Sorted(slices.Values(doc.Statuses))
+	want := []string{"approved", "done", "draft", "usable", "withheld"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+}
+
+func TestReadConfig_VariableErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+variable "required" {}
+
+locals {
+  a = local.b
+  b = local.a
+}
+`)
+
+	_, err := eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Variables: map[string]string{
+			"unknown": "value",
+		},
+	})
+
+	var confErr *eleconf.ConfigError
+	if !errors.As(err, &confErr) {
+		t.Fatalf("expected a configuration error, got: %v", err)
+	}
+
+	var summaries []string
+
+	for _, d := range confErr.Diagnostics {
+		summaries = append(summaries, d.Summary)
+	}
+
+	want := []string{
+		"No value for required variable",
+		"Undeclared variable",
+		"Cyclic local value",
+		"Cyclic local value",
+	}
+
+	if !slices.Equal(summaries, want) {
+		t.Errorf("diagnostics = %q, want %q", summaries, want)
+	}
+
+	_, err = eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Variables: map[string]string{
+			"required": "value",
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Cyclic local value") {
+		t.Errorf("expected cyclic local error, got: %v", err)
+	}
+}
+
+func TestReadConfig_EnvVariables(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+variable "meta_suffix" {
+  default = "+meta"
+}
+
+document "core/article" {
+  meta_doc = "core/article${var.meta_suffix}"
+}
+`)
+
+	conf, err := eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		EnvVariables: map[string]string{
+			"meta_suffix": "+extra",
+			"unknown":     "value",
+		},
+	})
+	if err != nil {
+		t.Fatalf("undeclared environment variable rejected: %v", err)
+	}
+
+	if got := conf.Documents[0].MetaDocType; got != "core/article+extra" {
+		t.Errorf("unexpected meta doc type %q", got)
+	}
+
+	conf, err = eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Variables: map[string]string{
+			"meta_suffix": "+flag",
+		},
+		EnvVariables: map[string]string{
+			"meta_suffix": "+extra",
+		},
+	})
+	if err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	if got := conf.Documents[0].MetaDocType; got != "core/article+flag" {
+		t.Errorf("expected the input value to take precedence, got %q",
+			got)
+	}
+}
+
+func TestReadConfig_IgnoreUndeclared(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+variable "region" {}
+`)
+
+	conf, err := eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Variables: map[string]string{
+			"region": "eu",
+			"other":  "value",
+		},
+		IgnoreUndeclared: true,
+	})
+	if err != nil {
+		t.Fatalf("undeclared variable not ignored: %v", err)
+	}
+
+	declared := conf.DeclaredVariables()
+	if !slices.Equal(declared, []string{"region"}) {
+		t.Errorf("unexpected declared variables: %q", declared)
+	}
+}
+
+func TestReadConfigFromDirectory_WorkflowProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "profiles.hcl", `
+workflow_profile "editorial" {
+  statuses = ["draft", "done", "approved", "usable"]
+  workflow = {
+    step_zero  = "draft"
+    checkpoint = "usable"
+    negative_checkpoint = "unpublished"
+    steps = ["draft", "done", "approved"]
+  }
+}
+`)
+	writeHCL(t, dir, "docs.hcl", `
+document "core/article" {
+  profile = "editorial"
+}
+
+document "core/event" {
+  profile       = "editorial"
+  add_statuses  = ["cancelled"]
+  drop_statuses = ["approved"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	article, event := conf.Documents[0], conf.Documents[1]
+
+	if !slices.Equal(article.Statuses, []string{
+		"draft", "done", "approved", "usable",
+	}) {
+		t.Errorf("unexpected article statuses: %v", article.Statuses)
+	}
+
+	if !slices.Equal(event.Statuses, []string{
+		"draft", "done", "usable", "cancelled",
+	}) {
+		t.Errorf("unexpected event statuses: %v", event.Statuses)
+	}
+
+	if !slices.Equal(event.Workflow.Steps, []string{"draft", "done"}) {
+		t.Errorf("unexpected event workflow steps: %v", event.Workflow.Steps)
+	}
+
+	if !slices.Equal(article.Workflow.Steps, []string{
+		"draft", "done", "approved",
+	}) {
+		t.Errorf("the profile workflow was modified: %v",
+			article.Workflow.Steps)
+	}
+}
+
+func TestReadConfigFromDirectory_UnknownProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+document "core/article" {
+  profile = "editorial"
+}
+`)
+
+	_, err := eleconf.ReadConfigFromDirectory(dir)
+	if err == nil || !strings.Contains(err.Error(), "Unknown workflow profile") {
+		t.Fatalf("expected unknown profile error, got: %v", err)
+	}
+}
+
+func TestReadConfigFromDirectory_DocumentSets(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "taxonomy.hcl", `
+documents "taxonomy" {
+  for_each = ["core/place", "core/person"]
+  statuses = ["usable"]
+}
+
+documents "bounded" {
+  for_each = {
+    channel = { meta = false }
+    section = { meta = true }
+  }
+
+  type               = "core/${each.key}"
+  statuses           = ["usable"]
+  bounded_collection = true
+  meta_doc           = each.value.meta ? "core/${each.key}+meta" : ""
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	var got []string
+
+	for _, doc := range conf.Documents {
+		got = append(got, fmt.Sprintf("%s %v %q",
+			doc.Type, doc.Bounded(), doc.MetaDocType))
+	}
+
+	want := []string{
+		`core/place false ""`,
+		`core/person false ""`,
+		`core/channel true ""`,
+		`core/section true "core/section+meta"`,
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("documents = %q, want %q", got, want)
+	}
+}
+
+func TestReadConfigFromDirectory_DocumentSetDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "taxonomy.hcl", `
+documents "taxonomy" {
+  for_each = ["core/place", "core/person"]
+  statuses = ["usable"]
+}
+`)
+	writeHCL(t, dir, "person.hcl", `
+document "core/person" {
+  statuses = ["usable"]
+}
+`)
+
+	_, err := eleconf.ReadConfigFromDirectory(dir)
+	if err == nil || !strings.Contains(err.Error(), "Duplicate document type") {
+		t.Fatalf("expected duplicate document error, got: %v", err)
+	}
+}
+
+func TestReadConfig_EnvironmentOverlay(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "base.hcl", `
+document "core/article" {
+  statuses = ["draft", "usable"]
+}
+
+document "core/flash" {
+  statuses = ["usable"]
+}
+
+schema_set "core" {
+  version    = "v1.0.0"
+  repository = "https://github.com/ttab/revisorschemas.git"
+  schemas    = ["core"]
+}
+
+metric "charcount" {}
+`)
+
+	envDir := eleconf.EnvironmentDir(dir, "stage")
+
+	err := os.MkdirAll(envDir, 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeHCL(t, envDir, "stage.hcl", `
+document "core/article" {
+  statuses = ["draft", "done", "usable"]
+}
+
+schema_set "core" {
+  version    = "v1.1.0"
+  repository = "https://github.com/ttab/revisorschemas.git"
+  schemas    = ["core"]
+}
+
+schema_set "experimental" {
+  version    = "v1.0.7-genai5"
+  repository = "https://github.com/ttab/revisorschemas.git"
+  schemas    = ["core-genai"]
+}
+
+remove {
+  documents = ["core/flash"]
+  metrics   = ["charcount"]
+}
+`)
+
+	// An empty overlay directory uses the base configuration.
+	err = os.MkdirAll(eleconf.EnvironmentDir(dir, "prod"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Environment: "prod",
+	})
+	if err != nil {
+		t.Fatalf("read prod configuration: %v", err)
+	}
+
+	if len(prod.Documents) != 2 || len(prod.SchemaSets) != 1 ||
+		len(prod.Metric) != 1 {
+		t.Errorf("prod configuration should be the base configuration")
+	}
+
+	stage, err := eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Environment: "stage",
+	})
+	if err != nil {
+		t.Fatalf("read stage configuration: %v", err)
+	}
+
+	if len(stage.Documents) != 1 ||
+		!slices.Equal(stage.Documents[0].Statuses,
+			[]string{"draft", "done", "usable"}) {
+		t.Errorf("unexpected stage documents: %+v", stage.Documents)
+	}
+
+	var sets []string
+
+	for _, s := range stage.SchemaSets {
+		sets = append(sets, s.Name+"@"+s.Version)
+	}
+
+	if !slices.Equal(sets, []string{
+		"core@v1.1.0", "experimental@v1.0.7-genai5",
+	}) {
+		t.Errorf("unexpected stage schema sets: %v", sets)
+	}
+
+	if len(stage.Metric) != 0 {
+		t.Errorf("expected the metric to be removed in stage")
+	}
+
+	stageLock, err := eleconf.EnvironmentLockFilePath(dir, "stage")
+	if err != nil || stageLock != filepath.Join(envDir, "schema.lock.json") {
+		t.Errorf("expected stage to have its own lock file, got %q: %v",
+			stageLock, err)
+	}
+
+	_, err = eleconf.ReadConfig(dir, eleconf.ConfigOptions{
+		Environment: "dev",
+	})
+	if err == nil {
+		t.Errorf("expected an unknown environment to be an error")
+	}
+
+	_, err = eleconf.EnvironmentLockFilePath(dir, "dev")
+	if err == nil {
+		t.Errorf("expected the lock file of an unknown environment " +
+			"to be an error")
+	}
+
+	baseDir := t.TempDir()
+
+	baseLock, err := eleconf.EnvironmentLockFilePath(baseDir, "dev")
+	if err != nil || baseLock != eleconf.LockFilePath(baseDir) {
+		t.Errorf("expected a configuration without environments to "+
+			"use the base lock file, got %q: %v", baseLock, err)
+	}
+}
+
+func TestReadConfigFromDirectory_Subdirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, sub := range []string{"news", "planning", ".hidden", "exemplars"} {
+		err := os.MkdirAll(filepath.Join(dir, sub), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeHCL(t, dir, "base.hcl", `
+document "core/organiser" {
+  statuses = ["usable"]
+}
+`)
+	writeHCL(t, filepath.Join(dir, "news"), "article.hcl", `
+document "core/article" {
+  statuses = ["draft", "usable"]
+}
+`)
+	writeHCL(t, filepath.Join(dir, "planning"), "event.hcl", `
+document "core/event" {
+  statuses = ["usable"]
+}
+`)
+	writeHCL(t, filepath.Join(dir, ".hidden"), "broken.hcl", `not valid {`)
+	writeHCL(t, filepath.Join(dir, "exemplars"), "broken.hcl", `not valid {`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	var types []string
+
+	for _, d := range conf.Documents {
+		types = append(types, d.Type)
+	}
+
+	if !slices.Equal(types, []string{
+		"core/organiser", "core/article", "core/event",
+	}) {
+		t.Errorf("unexpected document order: %v", types)
+	}
+}
+
+func TestReadConfigFromDirectory_Include(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "config")
+	shared := filepath.Join(root, "shared")
+
+	for _, d := range []string{dir, filepath.Join(shared, "sub")} {
+		err := os.MkdirAll(d, 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeHCL(t, dir, "main.hcl", `
+include = ["../shared", "../shared/profiles.hcl"]
+
+document "core/article" {
+  profile = "editorial"
+}
+`)
+	writeHCL(t, shared, "profiles.hcl", `
+workflow_profile "editorial" {
+  statuses = ["draft", "usable"]
+}
+`)
+	writeHCL(t, filepath.Join(shared, "sub"), "planning.hcl", `
+document "core/planning-item" {
+  statuses = ["usable"]
+}
+`)
+
+	diags := configDiagnostics(t, dir)
+
+	if len(diags) != 2 || diags[0].Summary != "Invalid include path" {
+		t.Errorf("expected includes outside of the configuration "+
+			"directory to be rejected by default, got: %v", diags)
+	}
+
+	opts := eleconf.ConfigOptions{IncludeRoot: root}
+
+	conf, err := eleconf.ReadConfig(dir, opts)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	if len(conf.WorkflowProfiles) != 1 {
+		t.Errorf("expected the profile to be included once, got %d",
+			len(conf.WorkflowProfiles))
+	}
+
+	if len(conf.Documents) != 2 ||
+		!slices.Equal(conf.Documents[0].Statuses,
+			[]string{"draft", "usable"}) {
+		t.Errorf("unexpected documents: %+v", conf.Documents)
+	}
+
+	writeHCL(t, dir, "dup.hcl", `
+include = ["../shared/sub/planning.hcl"]
+`)
+	writeHCL(t, dir, "other.hcl", `
+document "core/planning-item" {
+  statuses = ["usable"]
+}
+`)
+
+	rootDiagnostics := func() hcl.Diagnostics {
+		_, err := eleconf.ReadConfig(dir, opts)
+
+		var confErr *eleconf.ConfigError
+		if !errors.As(err, &confErr) {
+			t.Fatalf("expected a configuration error, got: %v", err)
+		}
+
+		return confErr.Diagnostics
+	}
+
+	diags = rootDiagnostics()
+
+	if len(diags) != 2 || diags[0].Summary != "Duplicate document type" ||
+		filepath.Base(diags[0].Subject.Filename) != "other.hcl" ||
+		filepath.Base(diags[1].Subject.Filename) != "planning.hcl" {
+		t.Errorf("expected a duplicate reported in other.hcl, got: %v", diags)
+	}
+
+	writeHCL(t, dir, "dup.hcl", `
+include = ["../missing.hcl"]
+`)
+
+	diags = rootDiagnostics()
+
+	if len(diags) != 1 || diags[0].Summary != "Included file not found" {
+		t.Errorf("expected a missing include diagnostic, got: %v", diags)
+	}
+
+	writeHCL(t, dir, "dup.hcl", fmt.Sprintf("include = [%q, %q]\n",
+		"../../outside.hcl", filepath.Join(filepath.Dir(root), "abs.hcl")))
+
+	diags = rootDiagnostics()
+
+	if len(diags) != 2 || diags[0].Summary != "Invalid include path" ||
+		diags[1].Summary != "Invalid include path" {
+		t.Errorf("expected includes outside of the include root to "+
+			"be rejected, got: %v", diags)
+	}
+}
+
+func TestReadConfigFromDirectory_JSON(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "profiles.hcl", `
+locals {
+  done = "usable"
+}
+
+workflow_profile "editorial" {
+  statuses = ["draft", "usable"]
+}
+`)
+	writeHCL(t, dir, "generated.hcl.json", `{
+  "document": {
+    "core/article": {
+      "profile": "editorial",
+      "add_statuses": ["withheld"]
+    },
+    "core/place": {
+      "statuses": ["${local.done}"],
+      "bounded_collection": true
+    }
+  },
+  "metric": {
+    "charcount": {"aggregation": "replace"}
+  }
+}`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	if len(conf.Documents) != 2 || len(conf.Metric) != 1 {
+		t.Fatalf("unexpected configuration: %+v", conf)
+	}
+
+	article := conf.Documents[0]
+
+	if article.Type != "core/article" || !slices.Equal(article.Statuses,
+		[]string{"draft", "usable", "withheld"}) {
+		t.Errorf("unexpected article configuration: %+v", article)
+	}
+
+	place := conf.Documents[1]
+
+	if !place.Bounded() ||
+		!slices.Equal(place.Statuses, []string{"usable"}) {
+		t.Errorf("unexpected place configuration: %+v", place)
+	}
+
+	writeHCL(t, dir, "place.hcl", `
+document "core/place" {
+  statuses = ["usable"]
+}
+`)
+
+	diags := configDiagnostics(t, dir)
+
+	if len(diags) != 2 || diags[0].Summary != "Duplicate document type" ||
+		filepath.Base(diags[0].Subject.Filename) != "place.hcl" ||
+		!strings.Contains(diags[0].Detail, "generated.hcl.json") {
+		t.Errorf("expected a duplicate of the JSON document, got: %v", diags)
+	}
+
+	writeHCL(t, dir, "generated.hcl.json", `{"document": [}`)
+
+	diags = configDiagnostics(t, dir)
+
+	if !diags.HasErrors() || diags[0].Subject == nil ||
+		filepath.Base(diags[0].Subject.Filename) != "generated.hcl.json" {
+		t.Errorf("expected a syntax error in the JSON file, got: %v", diags)
+	}
+}
+
+func TestReadConfigFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.hcl": {Data: []byte(`
+include = ["shared/profiles.hcl"]
+
+document "core/article" {
+  profile = "editorial"
+}
+`)},
+		"shared/profiles.hcl": {Data: []byte(`
+workflow_profile "editorial" {
+  statuses = ["draft", "usable"]
+}
+`)},
+		"environments/stage/article.hcl": {Data: []byte(`
+document "core/article" {
+  statuses = ["usable"]
+}
+`)},
+		"environments/stage/schema.lock.json": {Data: []byte(
+			`{"schemas": {"core": {"name": "core", "version": "v1.0.0", "hash": "x"}}}`)},
+		"exemplars/article.json": {Data: []byte(
+			`{"uri": "core://article/1", "type": "core/article"}`)},
+		"bad/outside.hcl": {Data: []byte(`include = ["../../other.hcl"]`)},
+	}
+
+	_, err := eleconf.ReadConfigFS(fsys, eleconf.ConfigOptions{
+		Environment: "stage",
+	})
+	if err == nil {
+		t.Fatal("expected an error for the include outside of the file system")
+	}
+
+	var confErr *eleconf.ConfigError
+	if !errors.As(err, &confErr) ||
+		confErr.Diagnostics[0].Summary != "Invalid include path" ||
+		confErr.Diagnostics[0].Subject.Filename != "bad/outside.hcl" {
+		t.Fatalf("expected an invalid include in bad/outside.hcl, got: %v", err)
+	}
+
+	delete(fsys, "bad/outside.hcl")
+
+	conf, err := eleconf.ReadConfigFS(fsys, eleconf.ConfigOptions{
+		Environment: "stage",
+	})
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	if len(conf.Documents) != 1 ||
+		!slices.Equal(conf.Documents[0].Statuses, []string{"usable"}) {
+		t.Errorf("expected the stage overlay to be applied, got: %+v",
+			conf.Documents)
+	}
+
+	lockName, err := eleconf.EnvironmentLockFileName(fsys, "stage")
+	if err != nil || lockName != "environments/stage/schema.lock.json" {
+		t.Errorf("unexpected lock file name %q: %v", lockName, err)
+	}
+
+	lock, err := eleconf.LoadLockFileFS(fsys, lockName)
+	if err != nil {
+		t.Fatalf("load lock file: %v", err)
+	}
+
+	if lock.Schemas["core"].Version != "v1.0.0" {
+		t.Errorf("unexpected lock file contents: %+v", lock)
+	}
+
+	exemplars, err := eleconf.LoadExemplarsFS(fsys)
+	if err != nil {
+		t.Fatalf("load exemplars: %v", err)
+	}
+
+	if len(exemplars) != 1 || exemplars[0].Lock.DocType != "core/article" {
+		t.Errorf("unexpected exemplars: %+v", exemplars)
+	}
+}
+
+func TestReadConfigFromDirectory_Extend(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "editorial.hcl", `
+workflow_profile "editorial" {
+  statuses = ["draft", "usable"]
+}
+
+document "core/article" {
+  profile  = "editorial"
+  variants = ["timeless"]
+
+  time_expression {
+    expression = ".meta(type='core/newsvalue').data{start}"
+  }
+}
+
+document "core/article#timeless" {
+  statuses = ["usable"]
+}
+`)
+	writeHCL(t, dir, "print.hcl", `
+extend "core/article" {
+  statuses = ["print-done"]
+  variants = ["print"]
+  meta_doc = "core/article+meta"
+
+  time_expression {
+    expression = ".meta(type='tt/print').data{date}"
+    layout     = "2006-01-02"
+  }
+
+  attachment "pdf" {
+    required       = false
+    match_mimetype = ["application/pdf"]
+  }
+}
+
+document "core/article#print" {
+  statuses = ["usable"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	article := conf.Documents[0]
+
+	if !slices.Equal(article.Statuses,
+		[]string{"draft", "usable", "print-done"}) {
+		t.Errorf("unexpected statuses: %v", article.Statuses)
+	}
+
+	if !slices.Equal(article.Variants, []string{"timeless", "print"}) {
+		t.Errorf("unexpected variants: %v", article.Variants)
+	}
+
+	if article.MetaDocType != "core/article+meta" ||
+		len(article.TimeExpressions) != 2 ||
+		len(article.Attachments) != 1 {
+		t.Errorf("extension not applied: %+v", article)
+	}
+
+	writeHCL(t, dir, "sports.hcl", `
+extend "core/article" {
+  meta_doc = "core/other+meta"
+}
+
+extend "core/missing" {
+  statuses = ["usable"]
+}
+`)
+
+	diags := configDiagnostics(t, dir)
+
+	if len(diags) != 2 {
+		t.Fatalf("expected two diagnostics, got: %v", diags)
+	}
+
+	if diags[0].Summary != "Conflicting document setting" ||
+		filepath.Base(diags[0].Subject.Filename) != "sports.hcl" ||
+		!strings.Contains(diags[0].Detail, "print.hcl:2") {
+		t.Errorf("expected a conflict naming sports.hcl and print.hcl, got: %v",
+			diags[0])
+	}
+
+	if diags[1].Summary != "Unknown document type" {
+		t.Errorf("expected an unknown document type, got: %v", diags[1])
+	}
+}
+
+func TestReadConfigFromDirectory_ExtendExplicitFalse(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "editorial.hcl", `
+document "core/place" {
+  statuses           = ["usable"]
+  bounded_collection = false
+}
+`)
+	writeHCL(t, dir, "print.hcl", `
+extend "core/place" {
+  bounded_collection = true
+}
+`)
+
+	diags := configDiagnostics(t, dir)
+
+	if len(diags) != 1 ||
+		diags[0].Summary != "Conflicting document setting" ||
+		filepath.Base(diags[0].Subject.Filename) != "print.hcl" ||
+		!strings.Contains(diags[0].Detail, "editorial.hcl:2") {
+		t.Errorf("expected a conflict naming print.hcl and editorial.hcl, got: %v",
+			diags)
+	}
+}
+
+func TestReadConfigFromDirectory_DocumentDefaults(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "defaults.hcl", `
+workflow_profile "editorial" {
+  statuses = ["draft", "usable"]
+}
+
+document_defaults "*/*" {
+  statuses               = ["usable"]
+  evict_noncurrent_after = "30d"
+}
+
+document_defaults "tt/*" {
+  statuses           = ["usable", "cancelled"]
+  bounded_collection = true
+  schema_types       = true
+
+  label_expression {
+    expression = ".meta(type='core/section').data{code}"
+    template   = "section-{{.code}}"
+  }
+}
+`)
+	writeHCL(t, dir, "documents.hcl", `
+document "tt/channel" {}
+
+document "tt/place" {
+  bounded_collection = false
+}
+
+extend "tt/channel" {
+  evict_noncurrent_after = "60d"
+
+  label_expression {
+    expression = ".meta(type='tt/channel').data{code}"
+    template   = "channel-{{.code}}"
+  }
+}
+
+document "tt/print-article" {
+  profile  = "editorial"
+  variants = ["web"]
+}
+
+document "tt/print-article#web" {
+  statuses = ["draft"]
+}
+
+document "core/article" {
+  statuses               = ["draft", "done", "usable"]
+  evict_noncurrent_after = "7d"
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	docs := make(map[string]eleconf.DocumentConfig)
+
+	for _, d := range conf.Documents {
+		docs[d.Type] = d
+	}
+
+	channel := docs["tt/channel"]
+
+	if !slices.Equal(channel.Statuses, []string{"usable", "cancelled"}) ||
+		!channel.Bounded() {
+		t.Errorf("expected the most specific defaults, got: %+v", channel)
+	}
+
+	if len(channel.LabelExpressions) != 2 ||
+		channel.EvictNoncurrentAfter != "60d" {
+		t.Errorf("expected the extension to add to and replace the defaults, got: %+v",
+			channel)
+	}
+
+	if place := docs["tt/place"]; place.BoundedCollection == nil ||
+		place.Bounded() {
+		t.Errorf("expected the document to override the default with false, got: %+v",
+			place)
+	}
+
+	printArticle := docs["tt/print-article"]
+
+	if !slices.Equal(printArticle.Statuses, []string{"draft", "usable"}) {
+		t.Errorf("expected the profile statuses, got: %v",
+			printArticle.Statuses)
+	}
+
+	if web := docs["tt/print-article#web"]; web.Bounded() {
+		t.Errorf("expected defaults not to apply to variants: %+v", web)
+	}
+
+	article := docs["core/article"]
+
+	if len(article.Statuses) != 3 || article.EvictNoncurrentAfter != "7d" {
+		t.Errorf("expected document settings to win: %+v", article)
+	}
+
+	diags := conf.AddSchemaDocuments(map[string]bool{
+		"tt/channel":  true,
+		"tt/category": true,
+		"core/place":  true,
+	})
+	if diags.HasErrors() {
+		t.Fatalf("add schema documents: %v", diags)
+	}
+
+	added := conf.Documents[len(conf.Documents)-1]
+
+	if len(conf.Documents) != 6 || added.Type != "tt/category" ||
+		!slices.Equal(added.Statuses, []string{"usable", "cancelled"}) {
+		t.Errorf("expected only tt/category to be added, got: %+v",
+			conf.Documents)
+	}
+
+	writeHCL(t, dir, "bad.hcl", `
+document_defaults "tt/[" {}
+`)
+
+	diags = configDiagnostics(t, dir)
+
+	if len(diags) != 1 || diags[0].Summary != "Invalid document pattern" {
+		t.Errorf("expected an invalid pattern, got: %v", diags)
+	}
+}
+
+func configDiagnostics(t *testing.T, dir string) hcl.Diagnostics {
+	t.Helper()
+
+	_, err := eleconf.ReadConfigFromDirectory(dir)
+
+	var confErr *eleconf.ConfigError
+	if !errors.As(err, &confErr) {
+		t.Fatalf("expected a configuration error, got: %v", err)
+	}
+
+	return confErr.Diagnostics
+}
+
+func TestConfig_Effective(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+workflow_profile "simple" {
+  statuses = ["draft", "usable"]
+}
+
+document "core/article" {
+  profile                = "simple"
+  variants               = ["timeless", "print"]
+  evict_noncurrent_after = "720h"
+}
+
+document "core/article#print" {
+  statuses = ["usable"]
+}
+
+metric "wordcount" {}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	eff, err := conf.Effective()
+	if err != nil {
+		t.Fatalf("resolve configuration: %v", err)
+	}
+
+	var types []string
+
+	for _, d := range eff.Documents {
+		types = append(types, d.Type)
+	}
+
+	if !slices.Equal(types, []string{
+		"core/article", "core/article#timeless", "core/article#print",
+	}) {
+		t.Errorf("unexpected documents: %v", types)
+	}
+
+	article := eff.Documents[0]
+
+	if article.Profile != "" || article.EvictNoncurrentAfter != "30d" ||
+		!slices.Equal(article.Statuses, []string{"draft", "usable"}) {
+		t.Errorf("unexpected article configuration: %+v", article)
+	}
+
+	if eff.Metric[0].Aggregation != eleconf.MetricAggregationReplace {
+		t.Errorf("expected aggregation to default to replace, got %q",
+			eff.Metric[0].Aggregation)
+	}
+
+	// The HCL encoding should load back to the same configuration.
+	rtDir := t.TempDir()
+
+	writeHCL(t, rtDir, "effective.hcl", string(eleconf.EncodeHCL(eff)))
+
+	rt, err := eleconf.ReadConfigFromDirectory(rtDir)
+	if err != nil {
+		t.Fatalf("read encoded configuration: %v", err)
+	}
+
+	rtEff, err := rt.Effective()
+	if err != nil {
+		t.Fatalf("resolve encoded configuration: %v", err)
+	}
+
+	a, _ := json.Marshal(eff)
+	b, _ := json.Marshal(rtEff)
+
+	if string(a) != string(b) {
+		t.Errorf("encoded configuration differs:\n%s\n%s", a, b)
+	}
+}
@@ -0,0 +1,285 @@
+package eleconf
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// documentDefaults is a "document_defaults" block with settings for all
+// document types that match a pattern.
+type documentDefaults struct {
+	Pattern string `hcl:"pattern,label"`
+	// SchemaTypes adds documents for the matching types declared in the
+	// schemas that haven't been configured.
+	SchemaTypes bool `hcl:"schema_types,optional"`
+
+	// Profile is the default workflow profile.
+	Profile string `hcl:"profile,optional"`
+	// Statuses are the default statuses, not used for documents with a
+	// profile.
+	Statuses []string `hcl:"statuses,optional"`
+	// Workflow is the default workflow, not used for documents with a
+	// profile.
+	Workflow *DocumentWorkflow `hcl:"workflow,optional"`
+	// Attachments are the default attachments, used if the document declares
+	// none.
+	Attachments []AttachmentConfig `hcl:"attachment,block"`
+	// BoundedCollection makes the matching documents bounded collections.
+	BoundedCollection *bool `hcl:"bounded_collection,optional"`
+	// TimeExpressions are the default time expressions, used if the document
+	// declares none.
+	TimeExpressions []TimeExpression `hcl:"time_expression,block"`
+	// LabelExpressions are the default label expressions, used if the
+	// document declares none.
+	LabelExpressions []LabelExpression `hcl:"label_expression,block"`
+	// EvictNoncurrentAfter is the default eviction period.
+	EvictNoncurrentAfter string `hcl:"evict_noncurrent_after,optional"`
+
+	DeclRange hcl.Range
+}
+
+// matches checks if the defaults apply to a document type. Variants only
+// match patterns that include a variant separator.
+func (d documentDefaults) matches(docType string) bool {
+	_, variant := ParseDocumentType(docType)
+	if variant != "" && !strings.Contains(d.Pattern, "#") {
+		return false
+	}
+
+	ok, _ := path.Match(d.Pattern, docType)
+
+	return ok
+}
+
+var defaultsSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "document_defaults", LabelNames: []string{"pattern"}},
+	},
+}
+
+// takeDefaults decodes the document_defaults blocks of the bodies, and
+// replaces the bodies with the remaining content.
+func takeDefaults(
+	bodies []hcl.Body, ctx *hcl.EvalContext,
+) ([]documentDefaults, hcl.Diagnostics) {
+	var (
+		defaults []documentDefaults
+		diags    hcl.Diagnostics
+	)
+
+	for i, body := range bodies {
+		content, remain, cDiags := body.PartialContent(defaultsSchema)
+
+		diags = append(diags, cDiags...)
+
+		for _, block := range content.Blocks {
+			d := documentDefaults{
+				Pattern:   block.Labels[0],
+				DeclRange: block.DefRange,
+			}
+
+			bDiags := gohcl.DecodeBody(block.Body, ctx, &d)
+
+			diags = append(diags, bDiags...)
+			if bDiags.HasErrors() {
+				continue
+			}
+
+			_, err := path.Match(d.Pattern, "")
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid document pattern",
+					Detail: fmt.Sprintf(
+						"The pattern %q is invalid: %v.",
+						d.Pattern, err),
+					Subject: d.DeclRange.Ptr(),
+				})
+
+				continue
+			}
+
+			if d.EvictNoncurrentAfter != "" {
+				_, err := parseEvictionPeriod(d.EvictNoncurrentAfter)
+				if err != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid evict_noncurrent_after",
+						Detail: fmt.Sprintf(
+							"Invalid evict_noncurrent_after for %q: %v.",
+							d.Pattern, err),
+						Subject: d.DeclRange.Ptr(),
+					})
+				}
+			}
+
+			defaults = append(defaults, d)
+		}
+
+		bodies[i] = remain
+	}
+
+	seen := make(map[string]hcl.Range, len(defaults))
+
+	for _, d := range defaults {
+		first, dup := seen[d.Pattern]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"document defaults", d.Pattern,
+				first, d.DeclRange)...)
+
+			continue
+		}
+
+		seen[d.Pattern] = d.DeclRange
+	}
+
+	return defaults, diags
+}
+
+// applyDefaults fills in the settings that a document doesn't set from the
+// matching defaults. The most specific, that is the longest, pattern wins.
+// Returns the names of the single value settings that were filled in, as
+// extensions may replace them.
+func applyDefaults(doc *DocumentConfig, defaults []documentDefaults) []string {
+	var (
+		matching []documentDefaults
+		filled   []string
+	)
+
+	for _, d := range defaults {
+		if d.matches(doc.Type) {
+			matching = append(matching, d)
+		}
+	}
+
+	slices.SortStableFunc(matching, func(a, b documentDefaults) int {
+		return cmp.Compare(len(b.Pattern), len(a.Pattern))
+	})
+
+	for _, d := range matching {
+		// Statuses and workflow from defaults would take precedence
+		// over a profile set on the document.
+		usesProfile := doc.Profile != ""
+
+		if doc.Profile == "" && doc.Statuses == nil && doc.Workflow == nil {
+			doc.Profile = d.Profile
+		}
+
+		if doc.Statuses == nil && !usesProfile {
+			doc.Statuses = slices.Clone(d.Statuses)
+		}
+
+		if doc.Workflow == nil && d.Workflow != nil && !usesProfile {
+			wf := *d.Workflow
+			wf.Steps = slices.Clone(wf.Steps)
+
+			doc.Workflow = &wf
+		}
+
+		if doc.Attachments == nil {
+			doc.Attachments = slices.Clone(d.Attachments)
+		}
+
+		if doc.BoundedCollection == nil && d.BoundedCollection != nil {
+			bounded := *d.BoundedCollection
+
+			doc.BoundedCollection = &bounded
+			filled = append(filled, "bounded_collection")
+		}
+
+		if doc.TimeExpressions == nil {
+			doc.TimeExpressions = slices.Clone(d.TimeExpressions)
+		}
+
+		if doc.LabelExpressions == nil {
+			doc.LabelExpressions = slices.Clone(d.LabelExpressions)
+		}
+
+		if doc.EvictNoncurrentAfter == "" && d.EvictNoncurrentAfter != "" {
+			doc.EvictNoncurrentAfter = d.EvictNoncurrentAfter
+			filled = append(filled, "evict_noncurrent_after")
+		}
+	}
+
+	return filled
+}
+
+// fillDefaults applies the defaults to the documents of the configuration.
+// The single value settings that were filled in are recorded in defaulted,
+// keyed by document type and setting name.
+func fillDefaults(
+	conf *Config, defaults []documentDefaults, defaulted map[string]bool,
+) {
+	for i := range conf.Documents {
+		doc := &conf.Documents[i]
+
+		for _, name := range []string{
+			"bounded_collection", "evict_noncurrent_after",
+		} {
+			delete(defaulted, doc.Type+"."+name)
+		}
+
+		for _, name := range applyDefaults(doc, defaults) {
+			defaulted[doc.Type+"."+name] = true
+		}
+	}
+}
+
+// AddSchemaDocuments adds documents for the types declared in the schemas
+// that match a "document_defaults" block with schema_types set. Types that
+// already are configured, are used as meta document types, or are outside of
+// the managed scope, are left alone. The new documents get their settings from the defaults.
+func (c *Config) AddSchemaDocuments(declared map[string]bool) hcl.Diagnostics {
+	skip := make(map[string]bool, len(c.Documents))
+
+	for _, doc := range c.Documents {
+		skip[doc.Type] = true
+
+		if doc.MetaDocType != "" {
+			skip[doc.MetaDocType] = true
+		}
+	}
+
+	var types []string
+
+	for t := range declared {
+		if !skip[t] && c.Manage.ManagesType(t) {
+			types = append(types, t)
+		}
+	}
+
+	slices.Sort(types)
+
+	profiles, _ := indexProfiles(c.WorkflowProfiles)
+
+	var diags hcl.Diagnostics
+
+	for _, t := range types {
+		idx := slices.IndexFunc(c.defaults, func(d documentDefaults) bool {
+			return d.SchemaTypes && d.matches(t)
+		})
+		if idx == -1 {
+			continue
+		}
+
+		doc := DocumentConfig{
+			Type:      t,
+			DeclRange: c.defaults[idx].DeclRange,
+		}
+
+		applyDefaults(&doc, c.defaults)
+
+		diags = append(diags, resolveDocumentProfile(&doc, profiles)...)
+
+		c.Documents = append(c.Documents, doc)
+	}
+
+	return diags
+}
@@ -2,7 +2,10 @@ package eleconf
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/ttab/elephant-api/repository"
@@ -38,30 +41,7 @@ func GetTypeConfigurationChanges(
 		var spec TypeConfigSpec
 
 		if current != nil && current.Configuration != nil {
-			s := TypeConfigSpec{
-				Bounded: current.Configuration.BoundedCollection,
-			}
-
-			for _, exp := range current.Configuration.TimeExpressions {
-				s.TimeExpressions = append(s.TimeExpressions,
-					TimeExpression{
-						Expression: exp.Expression,
-						Layout:     exp.Layout,
-						Timezone:   exp.Timezone,
-					})
-			}
-
-			for _, exp := range current.Configuration.LabelExpressions {
-				s.LabelExpressions = append(s.LabelExpressions,
-					LabelExpression{
-						Expression: exp.Expression,
-						Template:   exp.Template,
-					})
-			}
-
-			s.Variants = current.Configuration.Variants
-
-			spec = s
+			spec = rpcToTypeConfigSpec(current.Configuration)
 		}
 
 		currMap[typ] = spec
@@ -73,11 +53,18 @@ func GetTypeConfigurationChanges(
 			continue
 		}
 
+		eviction, err := doc.EvictionPeriod()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%q evict_noncurrent_after: %w", doc.Type, err)
+		}
+
 		wantMap[doc.Type] = TypeConfigSpec{
-			Bounded:          doc.BoundedCollection,
-			TimeExpressions:  doc.TimeExpressions,
-			LabelExpressions: doc.LabelExpressions,
-			Variants:         doc.Variants,
+			Bounded:              doc.Bounded(),
+			TimeExpressions:      doc.TimeExpressions,
+			LabelExpressions:     doc.LabelExpressions,
+			Variants:             doc.Variants,
+			EvictNoncurrentAfter: eviction,
 		}
 	}
 
@@ -130,11 +117,76 @@ func GetTypeConfigurationChanges(
 	return changes, nil
 }
 
+func rpcToTypeConfigSpec(c *repository.TypeConfiguration) TypeConfigSpec {
+	s := TypeConfigSpec{
+		Bounded: c.BoundedCollection,
+	}
+
+	for _, exp := range c.TimeExpressions {
+		s.TimeExpressions = append(s.TimeExpressions,
+			TimeExpression{
+				Expression: exp.Expression,
+				Layout:     exp.Layout,
+				Timezone:   exp.Timezone,
+			})
+	}
+
+	for _, exp := range c.LabelExpressions {
+		s.LabelExpressions = append(s.LabelExpressions,
+			LabelExpression{
+				Expression: exp.Expression,
+				Template:   exp.Template,
+			})
+	}
+
+	s.Variants = c.Variants
+	s.EvictNoncurrentAfter = time.Duration(c.EvictNoncurrentAfter) * day
+
+	return s
+}
+
+// TypeConfigSpec is the configuration of a document type. In JSON the
+// eviction period is given in days, like in the repository API.
 type TypeConfigSpec struct {
-	Bounded          bool
-	TimeExpressions  []TimeExpression
-	LabelExpressions []LabelExpression
-	Variants         []string
+	Bounded              bool              `json:"bounded"`
+	TimeExpressions      []TimeExpression  `json:"time_expressions"`
+	LabelExpressions     []LabelExpression `json:"label_expressions"`
+	Variants             []string          `json:"variants"`
+	EvictNoncurrentAfter time.Duration     `json:"-"`
+}
+
+// typeConfigSpecJSON is the JSON form of a TypeConfigSpec.
+type typeConfigSpecJSON struct {
+	typeConfigFields
+
+	EvictNoncurrentAfter int64 `json:"evict_noncurrent_after"`
+}
+
+// typeConfigFields has the fields of a TypeConfigSpec, but not its JSON
+// methods.
+type typeConfigFields TypeConfigSpec
+
+// MarshalJSON implements json.Marshaler.
+func (s TypeConfigSpec) MarshalJSON() ([]byte, error) {
+	return json.Marshal(typeConfigSpecJSON{
+		typeConfigFields:     typeConfigFields(s),
+		EvictNoncurrentAfter: int64(s.EvictNoncurrentAfter / day),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *TypeConfigSpec) UnmarshalJSON(data []byte) error {
+	var v typeConfigSpecJSON
+
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	*s = TypeConfigSpec(v.typeConfigFields)
+	s.EvictNoncurrentAfter = time.Duration(v.EvictNoncurrentAfter) * day
+
+	return nil
 }
 
 var _ ConfigurationChange = &TypeConfigurationChange{}
@@ -142,10 +194,15 @@ var _ ConfigurationChange = &TypeConfigurationChange{}
 type TypeConfigurationChange struct {
 	diff string
 
-	Operation ChangeOp
-	Type      string
-	Current   TypeConfigSpec
-	Wanted    TypeConfigSpec
+	Operation ChangeOp       `json:"operation,omitempty"`
+	Type      string         `json:"type"`
+	Current   TypeConfigSpec `json:"current"`
+	Wanted    TypeConfigSpec `json:"wanted"`
+}
+
+// Target implements TargetedChange.
+func (t *TypeConfigurationChange) Target() ChangeTarget {
+	return ChangeTarget{Kind: TargetDocumentType, Name: t.Type}
 }
 
 // Describe implements ConfigurationChange.
@@ -154,6 +211,47 @@ func (t *TypeConfigurationChange) Describe() (ChangeOp, string) {
 		"update type configuration for %q:\n%s", t.Type, t.diff)
 }
 
+// removals lists the settings that the change removes from the type
+// configuration. Expressions are matched by their expression, so changing
+// f.ex. the layout of a time expression isn't a removal.
+func (t *TypeConfigurationChange) removals() []string {
+	var removed []string
+
+	for _, exp := range t.Current.TimeExpressions {
+		kept := slices.ContainsFunc(t.Wanted.TimeExpressions,
+			func(w TimeExpression) bool {
+				return w.Expression == exp.Expression
+			})
+		if !kept {
+			removed = append(removed, fmt.Sprintf(
+				"time expression %q", exp.Expression))
+		}
+	}
+
+	for _, exp := range t.Current.LabelExpressions {
+		kept := slices.ContainsFunc(t.Wanted.LabelExpressions,
+			func(w LabelExpression) bool {
+				return w.Expression == exp.Expression
+			})
+		if !kept {
+			removed = append(removed, fmt.Sprintf(
+				"label expression %q", exp.Expression))
+		}
+	}
+
+	for _, v := range t.Current.Variants {
+		if !slices.Contains(t.Wanted.Variants, v) {
+			removed = append(removed, fmt.Sprintf("variant %q", v))
+		}
+	}
+
+	if t.Current.EvictNoncurrentAfter > 0 && t.Wanted.EvictNoncurrentAfter == 0 {
+		removed = append(removed, "eviction of non-current versions")
+	}
+
+	return removed
+}
+
 // Execute implements ConfigurationChange.
 func (t *TypeConfigurationChange) Execute(ctx context.Context, c Clients) error {
 	schemas := c.GetSchemas()
@@ -180,6 +278,7 @@ func (t *TypeConfigurationChange) Execute(ctx context.Context, c Clients) error
 	}
 
 	config.Variants = t.Wanted.Variants
+	config.EvictNoncurrentAfter = int64(t.Wanted.EvictNoncurrentAfter / day)
 
 	_, err := schemas.ConfigureType(ctx, &repository.ConfigureTypeRequest{
 		Type:          t.Type,
@@ -0,0 +1,288 @@
+package eleconf
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// CursorKind is the kind of value at a cursor position.
+type CursorKind int
+
+const (
+	CursorOther CursorKind = iota
+	// CursorDocumentType is the type label of a document or extend
+	// block.
+	CursorDocumentType
+	// CursorWorkflowStep is a string in the workflow steps of a document
+	// or workflow profile.
+	CursorWorkflowStep
+)
+
+// CursorContext describes the string at a cursor position in a
+// configuration file. Used for editor completion and navigation.
+type CursorContext struct {
+	Kind CursorKind
+	// Value is the contents of the string, and Prefix the part of it
+	// that is before the cursor.
+	Value  string
+	Prefix string
+	// Start and End are the byte offsets of the string contents.
+	Start int
+	End   int
+	// BlockType and BlockLabel identify the block that a workflow step
+	// belongs to.
+	BlockType  string
+	BlockLabel string
+	// Statuses are the statuses and added statuses declared in the block
+	// that a workflow step belongs to.
+	Statuses []string
+}
+
+// AnalyzeCursor returns the context of the string at a byte offset in a
+// configuration file. Works on the tokens of the file, so that it handles
+// incomplete files that are being edited.
+func AnalyzeCursor(src []byte, offset int) CursorContext {
+	tokens, _ := hclsyntax.LexConfig(src, "", hcl.InitialPos)
+
+	tokens = slices.DeleteFunc(tokens, func(t hclsyntax.Token) bool {
+		return t.Type == hclsyntax.TokenComment
+	})
+
+	open, end := stringAt(tokens, offset)
+	if open == -1 {
+		return CursorContext{}
+	}
+
+	start := tokens[open].Range.End.Byte
+
+	c := CursorContext{
+		Value:  string(src[start:end]),
+		Prefix: string(src[start:offset]),
+		Start:  start,
+		End:    end,
+	}
+
+	if isDocumentLabel(tokens, open) {
+		c.Kind = CursorDocumentType
+
+		return c
+	}
+
+	list := enclosingOpener(tokens, open)
+	if list == -1 || tokens[list].Type != hclsyntax.TokenOBrack ||
+		attributeName(tokens, list) != "steps" {
+		return c
+	}
+
+	obj := enclosingOpener(tokens, list)
+	if obj == -1 || tokens[obj].Type != hclsyntax.TokenOBrace ||
+		attributeName(tokens, obj) != "workflow" {
+		return c
+	}
+
+	block := enclosingOpener(tokens, obj)
+	if block == -1 || tokens[block].Type != hclsyntax.TokenOBrace {
+		return c
+	}
+
+	blockType, labels := blockHeader(tokens, block)
+	if len(labels) == 0 {
+		return c
+	}
+
+	c.Kind = CursorWorkflowStep
+	c.BlockType = blockType
+	c.BlockLabel = labels[0]
+	c.Statuses = slices.Concat(
+		blockStringList(tokens, block, "statuses"),
+		blockStringList(tokens, block, "add_statuses"),
+	)
+
+	return c
+}
+
+// stringAt returns the index of the opening quote of the string that
+// contains offset, and the byte offset of the end of the string contents.
+// Unterminated strings end at the end of the line.
+func stringAt(tokens hclsyntax.Tokens, offset int) (int, int) {
+	for i, t := range tokens {
+		if t.Range.Start.Byte > offset {
+			break
+		}
+
+		if t.Type != hclsyntax.TokenOQuote || t.Range.End.Byte > offset {
+			continue
+		}
+
+		j := i + 1
+
+		for j < len(tokens) && !isStringEnd(tokens[j].Type) {
+			j++
+		}
+
+		if j == len(tokens) {
+			return -1, 0
+		}
+
+		end := tokens[j].Range.Start.Byte
+		if offset <= end {
+			return i, end
+		}
+	}
+
+	return -1, 0
+}
+
+func isStringEnd(t hclsyntax.TokenType) bool {
+	return t == hclsyntax.TokenCQuote ||
+		t == hclsyntax.TokenNewline ||
+		t == hclsyntax.TokenEOF
+}
+
+// isDocumentLabel checks if the string starting at open is the label of a
+// document or extend block.
+func isDocumentLabel(tokens hclsyntax.Tokens, open int) bool {
+	prev := open - 1
+
+	if prev < 0 || tokens[prev].Type != hclsyntax.TokenIdent ||
+		!isLineStart(tokens, prev) {
+		return false
+	}
+
+	switch string(tokens[prev].Bytes) {
+	case "document", "extend":
+		return true
+	}
+
+	return false
+}
+
+func isLineStart(tokens hclsyntax.Tokens, i int) bool {
+	return i == 0 || tokens[i-1].Type == hclsyntax.TokenNewline
+}
+
+// enclosingOpener returns the index of the unmatched bracket, brace or
+// parenthesis before index i.
+func enclosingOpener(tokens hclsyntax.Tokens, i int) int {
+	depth := 0
+
+	for k := i - 1; k >= 0; k-- {
+		switch tokens[k].Type {
+		case hclsyntax.TokenCBrack, hclsyntax.TokenCBrace,
+			hclsyntax.TokenCParen:
+			depth++
+		case hclsyntax.TokenOBrack, hclsyntax.TokenOBrace,
+			hclsyntax.TokenOParen:
+			if depth == 0 {
+				return k
+			}
+
+			depth--
+		}
+	}
+
+	return -1
+}
+
+// attributeName returns the name of the attribute whose value starts at the
+// given index, or an empty string.
+func attributeName(tokens hclsyntax.Tokens, i int) string {
+	if i < 2 ||
+		tokens[i-1].Type != hclsyntax.TokenEqual ||
+		tokens[i-2].Type != hclsyntax.TokenIdent {
+		return ""
+	}
+
+	return string(tokens[i-2].Bytes)
+}
+
+// blockHeader returns the type and labels of the block whose body starts
+// with the brace at index i.
+func blockHeader(tokens hclsyntax.Tokens, i int) (string, []string) {
+	var labels []string
+
+	k := i - 1
+
+	for k >= 0 {
+		t := tokens[k]
+
+		switch t.Type {
+		case hclsyntax.TokenCQuote:
+			m := k - 1
+
+			var lit string
+
+			if m >= 0 && tokens[m].Type == hclsyntax.TokenQuotedLit {
+				lit = string(tokens[m].Bytes)
+				m--
+			}
+
+			if m < 0 || tokens[m].Type != hclsyntax.TokenOQuote {
+				return "", nil
+			}
+
+			labels = append([]string{lit}, labels...)
+			k = m - 1
+		case hclsyntax.TokenIdent:
+			if isLineStart(tokens, k) {
+				return string(t.Bytes), labels
+			}
+
+			labels = append([]string{string(t.Bytes)}, labels...)
+			k--
+		default:
+			return "", nil
+		}
+	}
+
+	return "", nil
+}
+
+// blockStringList returns the string literals of a list attribute in the
+// block whose body starts with the brace at index i.
+func blockStringList(
+	tokens hclsyntax.Tokens, i int, name string,
+) []string {
+	var values []string
+
+	depth := 0
+
+	for k := i + 1; k < len(tokens); k++ {
+		switch tokens[k].Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack,
+			hclsyntax.TokenOParen:
+			if depth == 0 && attributeName(tokens, k) == name &&
+				tokens[k].Type == hclsyntax.TokenOBrack {
+				values = append(values, listLiterals(tokens, k)...)
+			}
+
+			depth++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack,
+			hclsyntax.TokenCParen:
+			if depth == 0 {
+				return values
+			}
+
+			depth--
+		}
+	}
+
+	return values
+}
+
+func listLiterals(tokens hclsyntax.Tokens, open int) []string {
+	var values []string
+
+	for k := open + 1; k < len(tokens); k++ {
+		switch tokens[k].Type {
+		case hclsyntax.TokenQuotedLit:
+			values = append(values, string(tokens[k].Bytes))
+		case hclsyntax.TokenCBrack, hclsyntax.TokenEOF:
+			return values
+		}
+	}
+
+	return values
+}
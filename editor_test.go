@@ -0,0 +1,78 @@
+package eleconf_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+// analyzeAt analyzes the cursor at the position marked with "|".
+func analyzeAt(src string) eleconf.CursorContext {
+	offset := strings.Index(src, "|")
+
+	return eleconf.AnalyzeCursor(
+		[]byte(strings.Replace(src, "|", "", 1)), offset)
+}
+
+func TestAnalyzeCursor_DocumentType(t *testing.T) {
+	c := analyzeAt(`
+document "core/art|
+`)
+
+	if c.Kind != eleconf.CursorDocumentType || c.Prefix != "core/art" {
+		t.Errorf("expected a document type with prefix, got %+v", c)
+	}
+
+	c = analyzeAt(`
+document "core/article#pr|int" {
+  statuses = ["usable"]
+}
+`)
+
+	if c.Kind != eleconf.CursorDocumentType ||
+		c.Value != "core/article#print" || c.Prefix != "core/article#pr" {
+		t.Errorf("unexpected context for a complete label: %+v", c)
+	}
+}
+
+func TestAnalyzeCursor_WorkflowStep(t *testing.T) {
+	c := analyzeAt(`
+document "core/article" {
+  statuses     = ["draft", "done", "usable"] # Editorial statuses
+  add_statuses = ["approved"]
+
+  workflow = {
+    step_zero  = "draft"
+    checkpoint = "usable"
+    steps      = ["draft", "d|
+  }
+}
+`)
+
+	if c.Kind != eleconf.CursorWorkflowStep {
+		t.Fatalf("expected a workflow step, got %+v", c)
+	}
+
+	if c.BlockType != "document" || c.BlockLabel != "core/article" ||
+		c.Prefix != "d" {
+		t.Errorf("unexpected context: %+v", c)
+	}
+
+	if !slices.Equal(c.Statuses, []string{
+		"draft", "done", "usable", "approved",
+	}) {
+		t.Errorf("unexpected statuses: %v", c.Statuses)
+	}
+
+	c = analyzeAt(`
+document "core/article" {
+  statuses = ["dr|"]
+}
+`)
+
+	if c.Kind != eleconf.CursorOther {
+		t.Errorf("expected statuses to have no special context, got %+v", c)
+	}
+}
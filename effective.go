@@ -0,0 +1,71 @@
+package eleconf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Effective returns the configuration that eleconf acts on, with profiles
+// applied and defaults filled in. Metric aggregation defaults to "replace",
+// eviction periods are given in days, and every variant declared by a
+// document type gets a document entry directly after its base type.
+func (c *Config) Effective() (*Config, error) {
+	eff := Config{
+		SchemaSets: c.SchemaSets,
+		Manage:     c.Manage,
+	}
+
+	for _, m := range c.Metric {
+		if m.Aggregation == "" {
+			m.Aggregation = MetricAggregationReplace
+		}
+
+		eff.Metric = append(eff.Metric, m)
+	}
+
+	variantDocs := make(map[string]DocumentConfig)
+
+	for _, doc := range c.Documents {
+		_, variant := ParseDocumentType(doc.Type)
+		if variant != "" {
+			variantDocs[doc.Type] = doc
+		}
+	}
+
+	for _, doc := range c.Documents {
+		_, variant := ParseDocumentType(doc.Type)
+		if variant != "" {
+			continue
+		}
+
+		eviction, err := doc.EvictionPeriod()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%q evict_noncurrent_after: %w", doc.Type, err)
+		}
+
+		if eviction > 0 {
+			doc.EvictNoncurrentAfter = strconv.FormatInt(
+				int64(eviction/day), 10) + "d"
+		}
+
+		doc.Profile = ""
+
+		eff.Documents = append(eff.Documents, doc)
+
+		for _, v := range doc.Variants {
+			vType := doc.Type + "#" + v
+
+			vDoc, ok := variantDocs[vType]
+			if !ok {
+				vDoc = DocumentConfig{Type: vType}
+			}
+
+			vDoc.Profile = ""
+
+			eff.Documents = append(eff.Documents, vDoc)
+		}
+	}
+
+	return &eff, nil
+}
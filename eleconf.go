@@ -40,7 +40,13 @@ func (c *StaticClients) GetWorkflows() repository.Workflows {
 
 // GetChanges computes all configuration changes needed to bring the remote
 // state in line with the desired configuration. Schema changes use
-// RegisterGeneration with the given activation status.
+// RegisterGeneration with the given activation status. The changes are
+// returned in the order they must be executed in, see OrderChanges.
+//
+// GetChanges, like the Get*Changes functions of the separate domains,
+// doesn't apply the managed scope of the configuration, the changes include
+// differences for types, metrics and schemas that the configuration doesn't
+// manage. PlanChanges, or NewPlan, splits them by the scope.
 func GetChanges(
 	ctx context.Context,
 	clients Clients,
@@ -94,7 +100,12 @@ func GetChanges(
 
 	changes = append(changes, typChanges...)
 
-	return changes, nil
+	ordered, err := OrderChanges(changes)
+	if err != nil {
+		return nil, fmt.Errorf("order changes: %w", err)
+	}
+
+	return ordered, nil
 }
 
 // GetGenerationChanges computes only the schema generation change, skipping
@@ -0,0 +1,236 @@
+package eleconf
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// EncodeHCL encodes the configuration as formatted HCL. The configuration is
+// written as loaded, variables, locals and document sets have already been
+// resolved and aren't preserved.
+func EncodeHCL(conf *Config) []byte {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+
+	first := true
+
+	separate := func() {
+		if !first {
+			body.AppendNewline()
+		}
+
+		first = false
+	}
+
+	if conf.Manage != nil {
+		separate()
+
+		b := body.AppendNewBlock("manage", nil).Body()
+
+		setStrings(b, "types", conf.Manage.Types)
+		setStrings(b, "metrics", conf.Manage.Metrics)
+
+		if conf.Manage.Schemas != nil {
+			b.SetAttributeValue("schemas",
+				cty.BoolVal(*conf.Manage.Schemas))
+		}
+	}
+
+	for _, set := range conf.SchemaSets {
+		separate()
+		encodeSchemaSet(body, set)
+	}
+
+	for _, m := range conf.Metric {
+		separate()
+
+		b := body.AppendNewBlock("metric", []string{m.Kind}).Body()
+
+		if m.Aggregation != "" {
+			b.SetAttributeValue("aggregation",
+				cty.StringVal(string(m.Aggregation)))
+		}
+	}
+
+	for _, p := range conf.WorkflowProfiles {
+		separate()
+
+		b := body.AppendNewBlock(
+			"workflow_profile", []string{p.Name}).Body()
+
+		setStrings(b, "statuses", p.Statuses)
+		setWorkflow(b, p.Workflow)
+	}
+
+	for _, doc := range conf.Documents {
+		separate()
+		encodeDocument(body, doc)
+	}
+
+	return hclwrite.Format(f.Bytes())
+}
+
+func encodeSchemaSet(body *hclwrite.Body, set SchemaSet) {
+	b := body.AppendNewBlock("schema_set", []string{set.Name}).Body()
+
+	b.SetAttributeValue("version", cty.StringVal(set.Version))
+
+	if set.URLTemplate != "" {
+		b.SetAttributeValue("url_template", cty.StringVal(set.URLTemplate))
+	}
+
+	if set.Repository != "" {
+		b.SetAttributeValue("repository", cty.StringVal(set.Repository))
+	}
+
+	b.AppendNewline()
+	b.SetAttributeRaw("schemas", tokensForStrings(set.Schemas, true))
+}
+
+func encodeDocument(body *hclwrite.Body, doc DocumentConfig) {
+	b := body.AppendNewBlock("document", []string{doc.Type}).Body()
+
+	if doc.MetaDocType != "" {
+		b.SetAttributeValue("meta_doc", cty.StringVal(doc.MetaDocType))
+	}
+
+	if doc.Profile != "" {
+		b.SetAttributeValue("profile", cty.StringVal(doc.Profile))
+	}
+
+	setStrings(b, "statuses", doc.Statuses)
+	setStrings(b, "add_statuses", doc.AddStatuses)
+	setStrings(b, "drop_statuses", doc.DropStatuses)
+
+	if doc.Bounded() {
+		b.SetAttributeValue("bounded_collection", cty.True)
+	}
+
+	setStrings(b, "variants", doc.Variants)
+
+	if doc.EvictNoncurrentAfter != "" {
+		b.SetAttributeValue("evict_noncurrent_after",
+			cty.StringVal(doc.EvictNoncurrentAfter))
+	}
+
+	setWorkflow(b, doc.Workflow)
+
+	for _, exp := range doc.TimeExpressions {
+		b.AppendNewline()
+
+		eb := b.AppendNewBlock("time_expression", nil).Body()
+
+		eb.SetAttributeValue("expression", cty.StringVal(exp.Expression))
+
+		if exp.Layout != "" {
+			eb.SetAttributeValue("layout", cty.StringVal(exp.Layout))
+		}
+
+		if exp.Timezone != "" {
+			eb.SetAttributeValue("timezone", cty.StringVal(exp.Timezone))
+		}
+	}
+
+	for _, exp := range doc.LabelExpressions {
+		b.AppendNewline()
+
+		eb := b.AppendNewBlock("label_expression", nil).Body()
+
+		eb.SetAttributeValue("expression", cty.StringVal(exp.Expression))
+		eb.SetAttributeValue("template", cty.StringVal(exp.Template))
+	}
+
+	for _, att := range doc.Attachments {
+		b.AppendNewline()
+
+		ab := b.AppendNewBlock("attachment", []string{att.Name}).Body()
+
+		ab.SetAttributeValue("required", cty.BoolVal(att.Required))
+		ab.SetAttributeRaw("match_mimetype",
+			tokensForStrings(att.MatchMimetype, false))
+	}
+}
+
+func setStrings(body *hclwrite.Body, name string, values []string) {
+	if values == nil {
+		return
+	}
+
+	body.SetAttributeRaw(name, tokensForStrings(values, false))
+}
+
+func setWorkflow(body *hclwrite.Body, wf *DocumentWorkflow) {
+	if wf == nil {
+		return
+	}
+
+	if len(body.Attributes()) > 0 {
+		body.AppendNewline()
+	}
+
+	body.SetAttributeRaw("workflow", hclwrite.TokensForObject(
+		[]hclwrite.ObjectAttrTokens{
+			objectAttr("step_zero",
+				hclwrite.TokensForValue(cty.StringVal(wf.StepZero))),
+			objectAttr("checkpoint",
+				hclwrite.TokensForValue(cty.StringVal(wf.Checkpoint))),
+			objectAttr("negative_checkpoint",
+				hclwrite.TokensForValue(
+					cty.StringVal(wf.NegativeCheckpoint))),
+			objectAttr("steps", tokensForStrings(wf.Steps, false)),
+		}))
+}
+
+func objectAttr(name string, value hclwrite.Tokens) hclwrite.ObjectAttrTokens {
+	return hclwrite.ObjectAttrTokens{
+		Name:  hclwrite.TokensForIdentifier(name),
+		Value: value,
+	}
+}
+
+// maxInlineList is the maximum length of a list that is written on a single
+// line.
+const maxInlineList = 50
+
+// tokensForStrings writes a list of strings on a single line if it's short,
+// and with one element per line otherwise.
+func tokensForStrings(values []string, multiline bool) hclwrite.Tokens {
+	elems := make([]cty.Value, len(values))
+
+	for i, v := range values {
+		elems[i] = cty.StringVal(v)
+	}
+
+	if len(elems) == 0 {
+		return hclwrite.TokensForValue(cty.ListValEmpty(cty.String))
+	}
+
+	inline := hclwrite.TokensForValue(cty.TupleVal(elems))
+
+	if !multiline && len(values) <= 3 &&
+		len(strings.TrimSpace(string(inline.Bytes()))) <= maxInlineList {
+		return inline
+	}
+
+	toks := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+
+	for _, e := range elems {
+		toks = append(toks, hclwrite.TokensForValue(e)...)
+		toks = append(toks,
+			&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+
+	toks = append(toks, &hclwrite.Token{
+		Type: hclsyntax.TokenCBrack, Bytes: []byte("]"),
+	})
+
+	return toks
+}
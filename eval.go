@@ -0,0 +1,274 @@
+package eleconf
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// Variable is an input variable declared with a variable block. The value is
+// taken from ConfigOptions.Variables or ConfigOptions.EnvVariables, falling
+// back to the default.
+type Variable struct {
+	Name string `hcl:"name,label"`
+	// Default is the default value, the variable is required if there is
+	// none.
+	Default cty.Value `hcl:"default,optional"`
+	// Description describes the variable.
+	Description string    `hcl:"description,optional"`
+	DeclRange   hcl.Range `hcl:",def_range"`
+}
+
+// configFunctions returns the functions that are available in configuration
+// expressions.
+func configFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"abs":             stdlib.AbsoluteFunc,
+		"can":             tryfunc.CanFunc,
+		"ceil":            stdlib.CeilFunc,
+		"chomp":           stdlib.ChompFunc,
+		"chunklist":       stdlib.ChunklistFunc,
+		"coalesce":        stdlib.CoalesceFunc,
+		"coalescelist":    stdlib.CoalesceListFunc,
+		"compact":         stdlib.CompactFunc,
+		"concat":          stdlib.ConcatFunc,
+		"contains":        stdlib.ContainsFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"element":         stdlib.ElementFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"floor":           stdlib.FloorFunc,
+		"format":          stdlib.FormatFunc,
+		"formatlist":      stdlib.FormatListFunc,
+		"indent":          stdlib.IndentFunc,
+		"join":            stdlib.JoinFunc,
+		"jsondecode":      stdlib.JSONDecodeFunc,
+		"jsonencode":      stdlib.JSONEncodeFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          stdlib.LengthFunc,
+		"lookup":          stdlib.LookupFunc,
+		"lower":           stdlib.LowerFunc,
+		"max":             stdlib.MaxFunc,
+		"merge":           stdlib.MergeFunc,
+		"min":             stdlib.MinFunc,
+		"range":           stdlib.RangeFunc,
+		"regex":           stdlib.RegexFunc,
+		"regexall":        stdlib.RegexAllFunc,
+		"replace":         stdlib.ReplaceFunc,
+		"reverse":         stdlib.ReverseListFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setproduct":      stdlib.SetProductFunc,
+		"setsubtract":     stdlib.SetSubtractFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"slice":           stdlib.SliceFunc,
+		"sort":            stdlib.SortFunc,
+		"split":           stdlib.SplitFunc,
+		"strlen":          stdlib.StrlenFunc,
+		"substr":          stdlib.SubstrFunc,
+		"title":           stdlib.TitleFunc,
+		"trim":            stdlib.TrimFunc,
+		"trimprefix":      stdlib.TrimPrefixFunc,
+		"trimspace":       stdlib.TrimSpaceFunc,
+		"trimsuffix":      stdlib.TrimSuffixFunc,
+		"try":             tryfunc.TryFunc,
+		"upper":           stdlib.UpperFunc,
+		"values":          stdlib.ValuesFunc,
+		"zipmap":          stdlib.ZipmapFunc,
+	}
+}
+
+// evalVariables resolves the values of the declared variables. Input values
+// are parsed as strings, unless the default value is of another type, in
+// which case they are parsed as HCL expressions. Values from the environment
+// are used when there is no input value, and are ignored for undeclared
+// variables.
+func evalVariables(
+	declared []Variable, input map[string]string, env map[string]string,
+) (map[string]cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	values := make(map[string]cty.Value, len(declared))
+	decl := make(map[string]Variable, len(declared))
+
+	for _, v := range declared {
+		first, dup := decl[v.Name]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"variable", v.Name, first.DeclRange, v.DeclRange)...)
+
+			continue
+		}
+
+		decl[v.Name] = v
+
+		if !hclsyntax.ValidIdentifier(v.Name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable name",
+				Detail: fmt.Sprintf(
+					"The variable name %q is not a valid identifier.",
+					v.Name),
+				Subject: v.DeclRange.Ptr(),
+			})
+
+			continue
+		}
+
+		raw, ok := input[v.Name]
+		if !ok {
+			raw, ok = env[v.Name]
+		}
+
+		switch {
+		case !ok && v.Default == cty.NilVal:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "No value for required variable",
+				Detail: fmt.Sprintf(
+					"The variable %q has no default value, and no value was provided.",
+					v.Name),
+				Subject: v.DeclRange.Ptr(),
+			})
+		case !ok:
+			values[v.Name] = v.Default
+		case v.Default == cty.NilVal ||
+			v.Default.Type() == cty.String:
+			values[v.Name] = cty.StringVal(raw)
+		default:
+			val, vDiags := parseVariableValue(v.Name, raw)
+
+			diags = append(diags, vDiags...)
+			values[v.Name] = val
+		}
+	}
+
+	return values, diags
+}
+
+// checkUndeclared reports input values for variables that aren't declared in
+// the configuration in root.
+func checkUndeclared(
+	declared []Variable, input map[string]string, root string,
+) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, name := range slices.Sorted(maps.Keys(input)) {
+		if slices.ContainsFunc(declared, func(v Variable) bool {
+			return v.Name == name
+		}) {
+			continue
+		}
+
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Undeclared variable",
+			Detail: fmt.Sprintf(
+				"A value was provided for the variable %q, but it isn't declared in the configuration in %q.",
+				name, root),
+		})
+	}
+
+	return diags
+}
+
+func parseVariableValue(name string, raw string) (cty.Value, hcl.Diagnostics) {
+	expr, diags := hclsyntax.ParseExpression(
+		[]byte(raw), "<value for var."+name+">", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.DynamicVal, diags
+	}
+
+	return expr.Value(nil)
+}
+
+// evalLocals evaluates the attributes of all locals blocks. Locals may refer
+// to variables, functions, and each other, regardless of what file they were
+// declared in.
+func evalLocals(
+	attrs []*hcl.Attribute, ctx *hcl.EvalContext,
+) (map[string]cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	pending := make(map[string]*hcl.Attribute, len(attrs))
+
+	for _, attr := range attrs {
+		first, dup := pending[attr.Name]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"local value", attr.Name, first.NameRange, attr.NameRange)...)
+
+			continue
+		}
+
+		pending[attr.Name] = attr
+	}
+
+	values := make(map[string]cty.Value, len(pending))
+
+	for len(pending) > 0 {
+		progress := false
+
+		for _, name := range slices.Sorted(maps.Keys(pending)) {
+			attr := pending[name]
+
+			if dependsOnPending(attr.Expr, pending) {
+				continue
+			}
+
+			ctx.Variables["local"] = cty.ObjectVal(values)
+
+			val, vDiags := attr.Expr.Value(ctx)
+
+			diags = append(diags, vDiags...)
+			values[name] = val
+
+			delete(pending, name)
+
+			progress = true
+		}
+
+		if progress {
+			continue
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(pending)) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cyclic local value",
+				Detail: fmt.Sprintf(
+					"The local value %q depends on itself through other local values.",
+					name),
+				Subject: pending[name].NameRange.Ptr(),
+			})
+
+			values[name] = cty.DynamicVal
+		}
+
+		break
+	}
+
+	return values, diags
+}
+
+func dependsOnPending(
+	expr hcl.Expression, pending map[string]*hcl.Attribute,
+) bool {
+	for _, trav := range expr.Variables() {
+		if trav.RootName() != "local" || len(trav) < 2 {
+			continue
+		}
+
+		step, ok := trav[1].(hcl.TraverseAttr)
+		if ok && pending[step.Name] != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,25 +1,5 @@
 document "core/editorial-info" {
-  statuses = [
-    "draft",
-    "done",
-    "approved",
-    "withheld",
-    "cancelled",
-    "usable",
-  ]
-
-  workflow = {
-    step_zero  = "draft"
-    checkpoint = "usable"
-    negative_checkpoint = "unpublished"
-    steps      = [
-      "draft",
-      "done",
-      "approved",
-      "withheld",
-      "cancelled",
-    ]
-  }
+  profile = "editorial"
 }
 
 document "tt/editorial-info-type" {
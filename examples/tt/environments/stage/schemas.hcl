@@ -0,0 +1,9 @@
+# Experimental schemas are only used in stage.
+schema_set "experimental" {
+  version    = "v1.0.7-genai5"
+  repository = "https://github.com/ttab/revisorschemas.git"
+
+  schemas = [
+    "core-genai"
+  ]
+}
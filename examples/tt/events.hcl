@@ -1,21 +1,6 @@
 document "core/event" {
-  statuses = [
-    "draft",
-    "done",
-    "cancelled",
-    "usable",    
-  ]
-
-  workflow = {
-    step_zero  = "draft"
-    checkpoint = "usable"
-    negative_checkpoint = "unpublished"
-    steps      = [
-      "draft",
-      "done",
-      "cancelled",
-    ]
-  }
+  profile       = "editorial"
+  drop_statuses = ["approved", "withheld"]
 
   time_expression {
     expression = ".meta(type='core/event').data{start, end}"
@@ -1,21 +1,6 @@
 document "core/planning-item" {
-  statuses = [
-    "draft",
-    "done",
-    "cancelled",
-    "usable",
-  ]
-
-  workflow = {
-    step_zero  = "draft"
-    checkpoint = "usable"
-    negative_checkpoint = "unpublished"
-    steps = [
-      "draft",
-      "done",
-      "cancelled",
-    ]
-  }
+  profile       = "editorial"
+  drop_statuses = ["approved", "withheld"]
 
   time_expression {
     expression = ".meta(type='core/planning-item').data{start_date:date, end_date:date, tz=date_tz?}"
@@ -8,8 +8,8 @@ document "tt/print-article" {
   ]
 
   workflow = {
-    step_zero  = "draft"
-    checkpoint = "usable"
+    step_zero           = "draft"
+    checkpoint          = "usable"
     negative_checkpoint = "unpublished"
     steps = [
       "draft",
@@ -26,7 +26,7 @@ document "tt/print-layout" {
   ]
 
   attachment "layout" {
-    required       = true
+    required = true
     match_mimetype = [
       "application/vnd.scribus",
     ]
@@ -45,7 +45,7 @@ document "tt/tv-channel" {
   ]
 
   attachment "logo" {
-    required       = true
+    required = true
     match_mimetype = [
       "application/pdf",
     ]
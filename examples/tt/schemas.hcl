@@ -1,6 +1,6 @@
 schema_set "core" {
-  version      = "v1.0.6"
-  repository   = "https://github.com/ttab/revisorschemas.git"
+  version    = "v1.0.6"
+  repository = "https://github.com/ttab/revisorschemas.git"
 
   schemas = [
     "core",
@@ -20,13 +20,3 @@ schema_set "tt" {
     "tt-print",
   ]
 }
-
-schema_set "experimental" {
-  version    = "v1.0.7-genai5"
-  repository = "https://github.com/ttab/revisorschemas.git"
-
-  schemas = [
-    "core-genai"
-  ]
-}
-
@@ -1,36 +1,21 @@
-document "core/place" {
-  statuses = ["usable"]
-}
-
-document "core/person" {
-  statuses = ["usable"]
-}
-
-document "core/story" {
-  statuses = ["usable"]
-}
-
-document "core/channel" {
-  statuses = ["usable"]
+documents "taxonomy" {
+  for_each = [
+    "core/place",
+    "core/person",
+    "core/story",
+    "core/organisation",
+  ]
 
-  bounded_collection = true
-}
-
-document "core/section" {
-  statuses = ["usable"]
-
-  bounded_collection = true
-}
-
-document "core/organisation" {
   statuses = ["usable"]
 }
 
-document "core/organiser" {
-  statuses = ["usable"]
-}
+documents "bounded_taxonomy" {
+  for_each = [
+    "core/channel",
+    "core/section",
+    "core/category",
+  ]
 
-document "core/category" {
   statuses = ["usable"]
 
   bounded_collection = true
@@ -4,9 +4,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
+	"strings"
 
 	"github.com/ttab/newsdoc"
 )
@@ -30,10 +33,16 @@ type LoadedExemplar struct {
 // The document type is determined from the "type" field in each document, not
 // from the directory structure.
 func LoadExemplars(dir string) ([]LoadedExemplar, error) {
-	exemplarsDir := filepath.Join(dir, "exemplars")
+	return LoadExemplarsFS(os.DirFS(dir))
+}
+
+// LoadExemplarsFS recursively loads all .json files from the exemplars
+// directory of a configuration file system.
+func LoadExemplarsFS(fsys fs.FS) ([]LoadedExemplar, error) {
+	const exemplarsDir = "exemplars"
 
-	info, err := os.Stat(exemplarsDir)
-	if os.IsNotExist(err) {
+	info, err := fs.Stat(fsys, exemplarsDir)
+	if errors.Is(err, fs.ErrNotExist) {
 		return nil, nil
 	} else if err != nil {
 		return nil, fmt.Errorf("stat exemplars directory: %w", err)
@@ -46,20 +55,20 @@ func LoadExemplars(dir string) ([]LoadedExemplar, error) {
 
 	var exemplars []LoadedExemplar
 
-	err = filepath.Walk(exemplarsDir, func(
-		path string, info os.FileInfo, walkErr error,
+	err = fs.WalkDir(fsys, exemplarsDir, func(
+		name string, d fs.DirEntry, walkErr error,
 	) error {
 		if walkErr != nil {
 			return walkErr
 		}
 
-		if info.IsDir() || filepath.Ext(path) != ".json" {
+		if d.IsDir() || path.Ext(name) != ".json" {
 			return nil
 		}
 
-		ex, lErr := loadExemplarFile(path, exemplarsDir)
+		ex, lErr := loadExemplarFile(fsys, name)
 		if lErr != nil {
-			relPath, _ := filepath.Rel(exemplarsDir, path)
+			relPath := strings.TrimPrefix(name, exemplarsDir+"/")
 
 			return fmt.Errorf("load exemplar %q: %w", relPath, lErr)
 		}
@@ -75,8 +84,8 @@ func LoadExemplars(dir string) ([]LoadedExemplar, error) {
 	return exemplars, nil
 }
 
-func loadExemplarFile(path string, baseDir string) (LoadedExemplar, error) {
-	data, err := os.ReadFile(path)
+func loadExemplarFile(fsys fs.FS, filename string) (LoadedExemplar, error) {
+	data, err := fs.ReadFile(fsys, filename)
 	if err != nil {
 		return LoadedExemplar{}, fmt.Errorf("read file: %w", err)
 	}
@@ -98,8 +107,7 @@ func loadExemplarFile(path string, baseDir string) (LoadedExemplar, error) {
 		return LoadedExemplar{}, fmt.Errorf("canonicalize document: %w", err)
 	}
 
-	h := sha256.Sum256(canonical)
-	hash := "sha256:" + hex.EncodeToString(h[:])
+	hash := exemplarHash(canonical)
 
 	if doc.URI == "" {
 		return LoadedExemplar{}, fmt.Errorf("document has no uri field")
@@ -118,6 +126,13 @@ func loadExemplarFile(path string, baseDir string) (LoadedExemplar, error) {
 	}, nil
 }
 
+// exemplarHash hashes the canonical form of an exemplar.
+func exemplarHash(canonical []byte) string {
+	h := sha256.Sum256(canonical)
+
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
 // ExemplarLocks extracts the lock entries from loaded exemplars.
 func ExemplarLocks(exemplars []LoadedExemplar) []ExemplarLock {
 	locks := make([]ExemplarLock, len(exemplars))
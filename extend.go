@@ -0,0 +1,228 @@
+package eleconf
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// documentExtension is an "extend" block that adds to a document type
+// declared elsewhere, so that teams can contribute statuses, expressions and
+// attachments without editing each other's files.
+type documentExtension struct {
+	Type string `hcl:"type,label"`
+	// MetaDocType is the meta document type, if not set by the document or
+	// another extension.
+	MetaDocType string `hcl:"meta_doc,optional"`
+	// Statuses are statuses to add to the document type.
+	Statuses []string `hcl:"statuses,optional"`
+	// Attachments are attachments to add to the document type.
+	Attachments []AttachmentConfig `hcl:"attachment,block"`
+	// BoundedCollection sets whether the document type is a bounded
+	// collection, if not set by the document or another extension.
+	BoundedCollection *bool `hcl:"bounded_collection,optional"`
+	// TimeExpressions are time expressions to add to the document type.
+	TimeExpressions []TimeExpression `hcl:"time_expression,block"`
+	// LabelExpressions are label expressions to add to the document type.
+	LabelExpressions []LabelExpression `hcl:"label_expression,block"`
+	// Variants are variants to add to the document type.
+	Variants []string `hcl:"variants,optional"`
+	// EvictNoncurrentAfter is the eviction period, if not set by the
+	// document or another extension.
+	EvictNoncurrentAfter string `hcl:"evict_noncurrent_after,optional"`
+
+	DeclRange hcl.Range
+}
+
+var extensionSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "extend", LabelNames: []string{"type"}},
+	},
+}
+
+// takeExtensions decodes the extend blocks of the bodies, and replaces the
+// bodies with the remaining content.
+func takeExtensions(
+	bodies []hcl.Body, ctx *hcl.EvalContext,
+) ([]documentExtension, hcl.Diagnostics) {
+	var (
+		exts  []documentExtension
+		diags hcl.Diagnostics
+	)
+
+	for i, body := range bodies {
+		content, remain, cDiags := body.PartialContent(extensionSchema)
+
+		diags = append(diags, cDiags...)
+
+		for _, block := range content.Blocks {
+			ext := documentExtension{
+				Type:      block.Labels[0],
+				DeclRange: block.DefRange,
+			}
+
+			bDiags := gohcl.DecodeBody(block.Body, ctx, &ext)
+
+			diags = append(diags, bDiags...)
+			if bDiags.HasErrors() {
+				continue
+			}
+
+			if ext.EvictNoncurrentAfter != "" {
+				_, err := parseEvictionPeriod(ext.EvictNoncurrentAfter)
+				if err != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid evict_noncurrent_after",
+						Detail: fmt.Sprintf(
+							"Invalid evict_noncurrent_after for %q: %v.",
+							ext.Type, err),
+						Subject: ext.DeclRange.Ptr(),
+					})
+				}
+			}
+
+			exts = append(exts, ext)
+		}
+
+		bodies[i] = remain
+	}
+
+	return exts, diags
+}
+
+// applyExtensions merges extend blocks into the documents they extend. Lists
+// and blocks are added, and settings can only be set if they haven't been set
+// to something else by the document or another extension. Settings that were
+// filled in from document defaults, see fillDefaults, can be replaced.
+func applyExtensions(
+	conf *Config, exts []documentExtension, defaulted map[string]bool,
+) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	// Where document settings were set by extensions, so that conflicts
+	// can point at both files.
+	setBy := make(map[string]hcl.Range)
+
+	for _, ext := range exts {
+		idx := slices.IndexFunc(conf.Documents, func(d DocumentConfig) bool {
+			return d.Type == ext.Type
+		})
+		if idx == -1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown document type",
+				Detail: fmt.Sprintf(
+					"The document type %q is extended, but it hasn't been declared.",
+					ext.Type),
+				Subject: ext.DeclRange.Ptr(),
+			})
+
+			continue
+		}
+
+		doc := &conf.Documents[idx]
+
+		// Added as status additions so that they apply on top of
+		// workflow profiles.
+		doc.AddStatuses = append(doc.AddStatuses, ext.Statuses...)
+
+		doc.TimeExpressions = append(doc.TimeExpressions,
+			ext.TimeExpressions...)
+		doc.LabelExpressions = append(doc.LabelExpressions,
+			ext.LabelExpressions...)
+
+		for _, v := range ext.Variants {
+			if !slices.Contains(doc.Variants, v) {
+				doc.Variants = append(doc.Variants, v)
+			}
+		}
+
+		for _, att := range ext.Attachments {
+			if slices.ContainsFunc(doc.Attachments, func(a AttachmentConfig) bool {
+				return a.Name == att.Name
+			}) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate attachment",
+					Detail: fmt.Sprintf(
+						"The attachment %q of %q was already defined for the document at %s.",
+						att.Name, doc.Type, doc.DeclRange),
+					Subject: ext.DeclRange.Ptr(),
+				})
+
+				continue
+			}
+
+			doc.Attachments = append(doc.Attachments, att)
+		}
+
+		if ext.MetaDocType != "" {
+			diags = append(diags, extendSetting(setBy, defaulted, doc, ext,
+				"meta_doc", &doc.MetaDocType,
+				ext.MetaDocType, doc.MetaDocType != "")...)
+		}
+
+		if ext.EvictNoncurrentAfter != "" {
+			diags = append(diags, extendSetting(setBy, defaulted, doc, ext,
+				"evict_noncurrent_after", &doc.EvictNoncurrentAfter,
+				ext.EvictNoncurrentAfter,
+				doc.EvictNoncurrentAfter != "")...)
+		}
+
+		if ext.BoundedCollection != nil {
+			bounded := doc.Bounded()
+
+			diags = append(diags, extendSetting(setBy, defaulted, doc, ext,
+				"bounded_collection", &bounded,
+				*ext.BoundedCollection, doc.BoundedCollection != nil)...)
+
+			doc.BoundedCollection = &bounded
+		}
+	}
+
+	return diags
+}
+
+// extendSetting sets a document setting from an extension. Returns an error
+// if the setting already has been set to a different value, isSet tells if
+// the current value was set.
+func extendSetting[T comparable](
+	setBy map[string]hcl.Range, defaulted map[string]bool,
+	doc *DocumentConfig, ext documentExtension,
+	name string, current *T, value T, isSet bool,
+) hcl.Diagnostics {
+	key := doc.Type + "." + name
+
+	if defaulted[key] {
+		isSet = false
+	}
+
+	switch {
+	case isSet && *current == value:
+		return nil
+	case !isSet:
+		*current = value
+		setBy[key] = ext.DeclRange
+
+		delete(defaulted, key)
+
+		return nil
+	}
+
+	first, ok := setBy[key]
+	if !ok {
+		first = doc.DeclRange
+	}
+
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Conflicting document setting",
+		Detail: fmt.Sprintf(
+			"The extension of %q sets %s to %#v, but it was set to %#v at %s.",
+			doc.Type, name, value, *current, first),
+		Subject: ext.DeclRange.Ptr(),
+	}}
+}
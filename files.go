@@ -0,0 +1,297 @@
+package eleconf
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// skipDirs are directories in the root of a configuration directory that
+// don't contain configuration files.
+var skipDirs = map[string]bool{
+	"environments": true,
+	"exemplars":    true,
+}
+
+var includeSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "include"},
+	},
+}
+
+// configFS is a file system that configuration files are read from.
+// Configuration read from disk keeps OS paths in diagnostics, and can include
+// files by absolute path as long as they are in the include root.
+type configFS struct {
+	fsys fs.FS
+	// dir is the configuration directory in fsys.
+	dir string
+	// osRoot is the OS path of the root of fsys, and osDir the
+	// configuration directory as given by the caller. Both are empty if
+	// fsys isn't the OS file system.
+	osRoot string
+	osDir  string
+}
+
+// osConfigFS returns a file system for a configuration directory on disk.
+// The file system is rooted at includeRoot, or at the configuration
+// directory if it's empty, and includes can't reach files outside of it.
+func osConfigFS(dir string, includeRoot string) (*configFS, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve path %q: %w", dir, err)
+	}
+
+	root := abs
+
+	if includeRoot != "" {
+		root, err = filepath.Abs(includeRoot)
+		if err != nil {
+			return nil, fmt.Errorf("resolve path %q: %w", includeRoot, err)
+		}
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || !fs.ValidPath(filepath.ToSlash(rel)) {
+		return nil, fmt.Errorf(
+			"the configuration directory %q is outside of the include root %q",
+			dir, includeRoot)
+	}
+
+	return &configFS{
+		fsys:   os.DirFS(root),
+		dir:    filepath.ToSlash(rel),
+		osRoot: root,
+		osDir:  dir,
+	}, nil
+}
+
+// name returns the name of the configuration directory in diagnostics.
+func (c *configFS) name() string {
+	if c.osDir != "" {
+		return c.osDir
+	}
+
+	return c.dir
+}
+
+// filename returns the name of a file in diagnostics.
+func (c *configFS) filename(name string) string {
+	if c.osRoot == "" {
+		return name
+	}
+
+	rel, ok := strings.CutPrefix(name, c.dir+"/")
+	if c.dir == "." {
+		rel, ok = name, true
+	}
+
+	if ok {
+		return filepath.Join(c.osDir, filepath.FromSlash(rel))
+	}
+
+	return filepath.Join(c.osRoot, filepath.FromSlash(name))
+}
+
+// includePath resolves an include relative to the file that declares it.
+// Returns false if the include is outside of the file system.
+func (c *configFS) includePath(from string, inc string) (string, bool) {
+	if filepath.IsAbs(inc) {
+		if c.osRoot == "" {
+			return "", false
+		}
+
+		rel, err := filepath.Rel(c.osRoot, inc)
+		if err != nil {
+			return "", false
+		}
+
+		name := filepath.ToSlash(rel)
+
+		return name, fs.ValidPath(name)
+	}
+
+	name := path.Join(path.Dir(from), filepath.ToSlash(inc))
+
+	return name, fs.ValidPath(name)
+}
+
+// fileCollector parses configuration files and follows their include
+// directives. Every file is only parsed once, regardless of how many times
+// it's included.
+type fileCollector struct {
+	cfs    *configFS
+	parser *hclparse.Parser
+	// sources replace file contents, see ConfigOptions.Sources.
+	sources map[string][]byte
+	seen    map[string]bool
+	bodies  []hcl.Body
+	diags   hcl.Diagnostics
+}
+
+func newFileCollector(cfs *configFS, parser *hclparse.Parser) *fileCollector {
+	return &fileCollector{
+		cfs:    cfs,
+		parser: parser,
+		seen:   make(map[string]bool),
+	}
+}
+
+// take returns the bodies collected so far, and resets the list.
+func (fc *fileCollector) take() []hcl.Body {
+	b := fc.bodies
+
+	fc.bodies = nil
+
+	return b
+}
+
+// collectDir recursively parses all configuration files in a directory, in
+// lexical order. Hidden directories are skipped, and if root is true, so are
+// the directories in skipDirs.
+func (fc *fileCollector) collectDir(dir string, root bool) error {
+	return fs.WalkDir(fc.cfs.fsys, dir, func(
+		name string, d fs.DirEntry, err error,
+	) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			base := d.Name()
+
+			switch {
+			case name == dir:
+				return nil
+			case strings.HasPrefix(base, "."):
+				return fs.SkipDir
+			case root && path.Dir(name) == dir && skipDirs[base]:
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if !IsConfigFile(name) {
+			return nil
+		}
+
+		return fc.collectFile(name)
+	})
+}
+
+// IsConfigFile checks if a file name is a configuration file, either in
+// native HCL syntax (".hcl") or HCL JSON syntax (".hcl.json").
+func IsConfigFile(name string) bool {
+	return strings.HasSuffix(name, ".hcl") || IsJSONConfigFile(name)
+}
+
+// IsJSONConfigFile checks if a file name is a configuration file in HCL JSON
+// syntax.
+func IsJSONConfigFile(name string) bool {
+	return strings.HasSuffix(name, ".hcl.json")
+}
+
+func (fc *fileCollector) collectFile(name string) error {
+	if fc.seen[name] {
+		return nil
+	}
+
+	fc.seen[name] = true
+
+	filename := fc.cfs.filename(name)
+
+	src, ok := fc.sources[filename]
+	if !ok {
+		data, err := fs.ReadFile(fc.cfs.fsys, name)
+		if err != nil {
+			return fmt.Errorf("read %q: %w", filename, err)
+		}
+
+		src = data
+	}
+
+	var (
+		file  *hcl.File
+		diags hcl.Diagnostics
+	)
+
+	if IsJSONConfigFile(name) {
+		file, diags = fc.parser.ParseJSON(src, filename)
+	} else {
+		file, diags = fc.parser.ParseHCL(src, filename)
+	}
+
+	fc.diags = append(fc.diags, diags...)
+
+	if file == nil || diags.HasErrors() {
+		return nil
+	}
+
+	content, remain, diags := file.Body.PartialContent(includeSchema)
+
+	fc.diags = append(fc.diags, diags...)
+	fc.bodies = append(fc.bodies, remain)
+
+	attr, ok := content.Attributes["include"]
+	if !ok {
+		return nil
+	}
+
+	var includes []string
+
+	diags = gohcl.DecodeExpression(attr.Expr, nil, &includes)
+
+	fc.diags = append(fc.diags, diags...)
+
+	for _, inc := range includes {
+		incPath, ok := fc.cfs.includePath(name, inc)
+		if !ok {
+			fc.diags = append(fc.diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid include path",
+				Detail: fmt.Sprintf(
+					"The included path %q is outside of the include root.",
+					inc),
+				Subject: attr.Expr.Range().Ptr(),
+			})
+
+			continue
+		}
+
+		info, err := fs.Stat(fc.cfs.fsys, incPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			fc.diags = append(fc.diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Included file not found",
+				Detail: fmt.Sprintf(
+					"The included path %q doesn't exist.", inc),
+				Subject: attr.Expr.Range().Ptr(),
+			})
+
+			continue
+		} else if err != nil {
+			return fmt.Errorf("stat included path %q: %w", inc, err)
+		}
+
+		if info.IsDir() {
+			err = fc.collectDir(incPath, false)
+		} else {
+			err = fc.collectFile(incPath)
+		}
+
+		if err != nil {
+			return fmt.Errorf("include %q: %w", inc, err)
+		}
+	}
+
+	return nil
+}
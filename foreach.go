@@ -0,0 +1,138 @@
+package eleconf
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var documentSetSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "documents", LabelNames: []string{"name"}},
+	},
+}
+
+var documentSetBodySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "for_each", Required: true},
+		{Name: "type"},
+	},
+}
+
+// expandDocumentSets expands the "documents" blocks in a body into document
+// configurations. The rest of the block body is decoded like a document
+// block once for every element in for_each, with each.key and each.value
+// available to expressions. The document type defaults to each.key.
+func expandDocumentSets(
+	body hcl.Body, ctx *hcl.EvalContext,
+) ([]DocumentConfig, hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := body.PartialContent(documentSetSchema)
+
+	var docs []DocumentConfig
+
+	for _, block := range content.Blocks {
+		d, bDiags := expandDocumentSet(block, ctx)
+
+		diags = append(diags, bDiags...)
+		docs = append(docs, d...)
+	}
+
+	return docs, remain, diags
+}
+
+func expandDocumentSet(
+	block *hcl.Block, ctx *hcl.EvalContext,
+) ([]DocumentConfig, hcl.Diagnostics) {
+	content, remain, diags := block.Body.PartialContent(documentSetBodySchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	forEach := content.Attributes["for_each"]
+
+	val, vDiags := forEach.Expr.Value(ctx)
+
+	diags = append(diags, vDiags...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	ty := val.Type()
+
+	isCollection := ty.IsListType() || ty.IsSetType() || ty.IsTupleType() ||
+		ty.IsMapType() || ty.IsObjectType()
+
+	if val.IsNull() || !val.IsWhollyKnown() || !isCollection {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid for_each value",
+			Detail:   "The for_each value must be a list, set or map.",
+			Subject:  forEach.Expr.Range().Ptr(),
+		})
+	}
+
+	byKey := ty.IsMapType() || ty.IsObjectType()
+
+	var docs []DocumentConfig
+
+	for it := val.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+
+		if !byKey {
+			key = value
+		}
+
+		if key.Type() != cty.String || key.IsNull() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid for_each element",
+				Detail:   "The elements of a for_each list or set must be strings.",
+				Subject:  forEach.Expr.Range().Ptr(),
+			})
+
+			continue
+		}
+
+		each := ctx.NewChild()
+		each.Variables = map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{
+				"key":   key,
+				"value": value,
+			}),
+		}
+
+		doc := DocumentConfig{
+			Type:      key.AsString(),
+			DeclRange: block.DefRange,
+		}
+
+		if attr, ok := content.Attributes["type"]; ok {
+			diags = append(diags, gohcl.DecodeExpression(
+				attr.Expr, each, &doc.Type)...)
+		}
+
+		dDiags := gohcl.DecodeBody(remain, each, &doc)
+
+		diags = append(diags, dDiags...)
+		if dDiags.HasErrors() {
+			continue
+		}
+
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 && !diags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Empty document set",
+			Detail: fmt.Sprintf(
+				"The documents block %q doesn't declare any documents.",
+				block.Labels[0]),
+			Subject: block.DefRange.Ptr(),
+		})
+	}
+
+	return docs, diags
+}
@@ -0,0 +1,118 @@
+package eleconf
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FormatOptions control how configuration files are formatted.
+type FormatOptions struct {
+	// SortLists sorts lists where the order has no meaning, like
+	// document statuses.
+	SortLists bool
+}
+
+// unorderedLists are the attributes whose list order doesn't affect the
+// resulting configuration.
+var unorderedLists = map[string]bool{
+	"statuses":       true,
+	"add_statuses":   true,
+	"drop_statuses":  true,
+	"schemas":        true,
+	"match_mimetype": true,
+}
+
+// FormatFile returns the canonical formatting of a configuration file.
+// Files with syntax errors are returned as diagnostics.
+func FormatFile(
+	src []byte, filename string, opts FormatOptions,
+) ([]byte, hcl.Diagnostics) {
+	// Parse with hclsyntax first, it reports problems that hclwrite
+	// accepts.
+	_, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	f, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if opts.SortLists {
+		sortLists(f.Body())
+	}
+
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+func sortLists(body *hclwrite.Body) {
+	for name, attr := range body.Attributes() {
+		if !unorderedLists[name] {
+			continue
+		}
+
+		toks := attr.Expr().BuildTokens(nil)
+
+		values, ok := literalStringList(toks)
+		if !ok || slices.IsSorted(values) {
+			continue
+		}
+
+		slices.Sort(values)
+
+		multiline := slices.ContainsFunc(toks, func(t *hclwrite.Token) bool {
+			return t.Type == hclsyntax.TokenNewline
+		})
+
+		body.SetAttributeRaw(name, tokensForStrings(values, multiline))
+	}
+
+	for _, block := range body.Blocks() {
+		sortLists(block.Body())
+	}
+}
+
+// literalStringList returns the values of a list expression that only
+// contains string literals. Lists with comments are left alone, as there's
+// no telling what the comments refer to.
+func literalStringList(toks hclwrite.Tokens) ([]string, bool) {
+	if slices.ContainsFunc(toks, func(t *hclwrite.Token) bool {
+		return t.Type == hclsyntax.TokenComment
+	}) {
+		return nil, false
+	}
+
+	expr, diags := hclsyntax.ParseExpression(
+		toks.Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	tuple, ok := expr.(*hclsyntax.TupleConsExpr)
+	if !ok {
+		return nil, false
+	}
+
+	values := make([]string, 0, len(tuple.Exprs))
+
+	for _, e := range tuple.Exprs {
+		tpl, ok := e.(*hclsyntax.TemplateExpr)
+		if !ok || !tpl.IsStringLiteral() {
+			return nil, false
+		}
+
+		v, diags := tpl.Value(nil)
+		if diags.HasErrors() || v.Type() != cty.String {
+			return nil, false
+		}
+
+		values = append(values, v.AsString())
+	}
+
+	return values, true
+}
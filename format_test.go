@@ -0,0 +1,60 @@
+package eleconf_test
+
+import (
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+func TestFormatFile(t *testing.T) {
+	src := `document "core/article" {
+  statuses = ["usable", "draft",   "done"]   
+  drop_statuses = [
+    "withheld", # Not used by the desk
+    "approved",
+  ]
+  variants = ["timeless", "print"]
+  workflow = {
+    step_zero = "draft"
+    steps = ["draft", "done"]
+  }
+}
+`
+
+	want := `document "core/article" {
+  statuses = ["done", "draft", "usable"]
+  drop_statuses = [
+    "withheld", # Not used by the desk
+    "approved",
+  ]
+  variants = ["timeless", "print"]
+  workflow = {
+    step_zero = "draft"
+    steps     = ["draft", "done"]
+  }
+}
+`
+
+	got, diags := eleconf.FormatFile([]byte(src), "test.hcl",
+		eleconf.FormatOptions{SortLists: true})
+	if diags.HasErrors() {
+		t.Fatalf("format: %v", diags)
+	}
+
+	if string(got) != want {
+		t.Errorf("unexpected formatting, got:\n%s", got)
+	}
+
+	again, _ := eleconf.FormatFile(got, "test.hcl",
+		eleconf.FormatOptions{SortLists: true})
+
+	if string(again) != string(got) {
+		t.Errorf("formatting isn't stable, got:\n%s", again)
+	}
+
+	_, diags = eleconf.FormatFile([]byte(`document "x" {`), "broken.hcl",
+		eleconf.FormatOptions{})
+	if !diags.HasErrors() {
+		t.Error("expected syntax errors to be reported")
+	}
+}
@@ -0,0 +1,322 @@
+package eleconf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	rpcdoc "github.com/ttab/elephant-api/newsdoc"
+	"github.com/ttab/elephant-api/repository"
+	"github.com/ttab/elephantine"
+	"github.com/ttab/newsdoc"
+	"github.com/twitchtv/twirp"
+)
+
+// ImportedConfig is the configuration read from a live repository.
+type ImportedConfig struct {
+	Config    *Config
+	Exemplars []newsdoc.Document
+}
+
+// ImportConfig reads the current configuration of a repository. The active
+// schemas are declared as schema sets, one per version, that load the
+// schemas from the git repository schemaRepository.
+func ImportConfig(
+	ctx context.Context,
+	clients Clients,
+	schemaRepository string,
+) (*ImportedConfig, error) {
+	schemas := clients.GetSchemas()
+	workflows := clients.GetWorkflows()
+
+	var conf Config
+
+	active, err := schemas.ListActive(ctx,
+		&repository.ListActiveSchemasRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("get active schemas: %w", err)
+	}
+
+	conf.SchemaSets = importSchemaSets(active.Schemas, schemaRepository)
+
+	kinds, err := clients.GetMetrics().GetKinds(ctx,
+		&repository.GetMetricKindsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("get metric kinds: %w", err)
+	}
+
+	for _, k := range kinds.Kinds {
+		agg := MetricAggregationReplace
+		if k.Aggregation == repository.MetricAggregation_INCREMENT {
+			agg = MetricAggregationIncrement
+		}
+
+		conf.Metric = append(conf.Metric, MetricKind{
+			Kind:        k.Name,
+			Aggregation: agg,
+		})
+	}
+
+	slices.SortFunc(conf.Metric, func(a, b MetricKind) int {
+		return strings.Compare(a.Kind, b.Kind)
+	})
+
+	metaTypes, err := schemas.GetMetaTypes(ctx,
+		&repository.GetMetaTypesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("get meta types: %w", err)
+	}
+
+	metaDocs := make(map[string]string)
+
+	for _, m := range metaTypes.Types {
+		for _, main := range m.UsedBy {
+			metaDocs[main] = m.Name
+		}
+	}
+
+	types, err := schemas.GetDocumentTypes(ctx,
+		&repository.GetDocumentTypesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("get document types: %w", err)
+	}
+
+	docTypes := slices.Clone(types.Types)
+
+	slices.Sort(docTypes)
+
+	for _, typ := range docTypes {
+		doc := DocumentConfig{
+			Type:        typ,
+			MetaDocType: metaDocs[typ],
+		}
+
+		doc.Statuses, err = importStatuses(ctx, workflows, typ)
+		if err != nil {
+			return nil, err
+		}
+
+		wf, err := workflows.GetWorkflow(ctx,
+			&repository.GetWorkflowRequest{Type: typ})
+		if err != nil && !elephantine.IsTwirpErrorCode(err, twirp.NotFound) {
+			return nil, fmt.Errorf("get workflow for %q: %w", typ, err)
+		}
+
+		if err == nil && wf.Workflow != nil {
+			doc.Workflow = rpcToWorkflow(wf.Workflow)
+		}
+
+		tc, err := schemas.GetTypeConfiguration(ctx,
+			&repository.GetTypeConfigurationRequest{Type: typ})
+		if err != nil && !elephantine.IsTwirpErrorCode(err, twirp.NotFound) {
+			return nil, fmt.Errorf(
+				"get type configuration for %q: %w", typ, err)
+		}
+
+		if err == nil && tc.Configuration != nil {
+			importTypeConfiguration(&doc, tc.Configuration)
+		}
+
+		if isEmptyDocument(doc) {
+			continue
+		}
+
+		conf.Documents = append(conf.Documents, doc)
+
+		// Variant workflows aren't read back when calculating changes,
+		// so only their statuses are imported.
+		for _, variant := range doc.Variants {
+			vType := typ + "#" + variant
+
+			statuses, err := importStatuses(ctx, workflows, vType)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(statuses) == 0 {
+				continue
+			}
+
+			conf.Documents = append(conf.Documents, DocumentConfig{
+				Type:     vType,
+				Statuses: statuses,
+			})
+		}
+	}
+
+	imported := ImportedConfig{
+		Config: &conf,
+	}
+
+	if active.GenerationId > 0 {
+		res, err := schemas.GetExemplars(ctx,
+			&repository.GetExemplarsRequest{
+				GenerationId: active.GenerationId,
+			})
+		if err != nil {
+			return nil, fmt.Errorf("get exemplars: %w", err)
+		}
+
+		for _, ex := range res.Exemplars {
+			if ex.Document == nil {
+				continue
+			}
+
+			imported.Exemplars = append(imported.Exemplars,
+				rpcdoc.DocumentFromRPC(ex.Document))
+		}
+	}
+
+	return &imported, nil
+}
+
+func importSchemaSets(
+	active []*repository.Schema, schemaRepository string,
+) []SchemaSet {
+	var sets []SchemaSet
+
+	byVersion := make(map[string]int)
+
+	for _, s := range active {
+		idx, ok := byVersion[s.Version]
+		if !ok {
+			idx = len(sets)
+			byVersion[s.Version] = idx
+
+			sets = append(sets, SchemaSet{
+				Version:    s.Version,
+				Repository: schemaRepository,
+			})
+		}
+
+		sets[idx].Schemas = append(sets[idx].Schemas, s.Name)
+	}
+
+	// Name the sets after their first schema, which will be the shortest
+	// name in families like "core", "core-planning".
+	for i := range sets {
+		slices.Sort(sets[i].Schemas)
+
+		sets[i].Name = sets[i].Schemas[0]
+	}
+
+	slices.SortFunc(sets, func(a, b SchemaSet) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return sets
+}
+
+func importStatuses(
+	ctx context.Context, workflows repository.Workflows, typ string,
+) ([]string, error) {
+	res, err := workflows.GetStatuses(ctx,
+		&repository.GetStatusesRequest{Type: typ})
+	if err != nil {
+		return nil, fmt.Errorf("get statuses for %q: %w", typ, err)
+	}
+
+	var statuses []string
+
+	for _, s := range res.Statuses {
+		statuses = append(statuses, s.Name)
+	}
+
+	return statuses, nil
+}
+
+func importTypeConfiguration(
+	doc *DocumentConfig, tc *repository.TypeConfiguration,
+) {
+	if tc.BoundedCollection {
+		doc.BoundedCollection = &tc.BoundedCollection
+	}
+	doc.Variants = tc.Variants
+
+	for _, exp := range tc.TimeExpressions {
+		doc.TimeExpressions = append(doc.TimeExpressions, TimeExpression{
+			Expression: exp.Expression,
+			Layout:     exp.Layout,
+			Timezone:   exp.Timezone,
+		})
+	}
+
+	for _, exp := range tc.LabelExpressions {
+		doc.LabelExpressions = append(doc.LabelExpressions, LabelExpression{
+			Expression: exp.Expression,
+			Template:   exp.Template,
+		})
+	}
+
+	if tc.EvictNoncurrentAfter > 0 {
+		doc.EvictNoncurrentAfter = strconv.FormatInt(
+			tc.EvictNoncurrentAfter, 10) + "d"
+	}
+}
+
+// isEmptyDocument returns true if a document has no configuration, and only
+// exists because its type has been declared in a schema.
+func isEmptyDocument(doc DocumentConfig) bool {
+	return doc.MetaDocType == "" &&
+		len(doc.Statuses) == 0 &&
+		doc.Workflow == nil &&
+		!doc.Bounded() &&
+		len(doc.Variants) == 0 &&
+		len(doc.TimeExpressions) == 0 &&
+		len(doc.LabelExpressions) == 0 &&
+		doc.EvictNoncurrentAfter == ""
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Write writes the imported configuration to a directory as "schemas.hcl",
+// "metrics.hcl" and "documents.hcl", and the exemplars to "exemplars/".
+// Existing files are overwritten.
+func (ic *ImportedConfig) Write(dir string) error {
+	files := map[string]*Config{
+		"schemas.hcl":   {SchemaSets: ic.Config.SchemaSets},
+		"metrics.hcl":   {Metric: ic.Config.Metric},
+		"documents.hcl": {Documents: ic.Config.Documents},
+	}
+
+	for name, conf := range files {
+		err := os.WriteFile(filepath.Join(dir, name),
+			EncodeHCL(conf), 0o600)
+		if err != nil {
+			return fmt.Errorf("write %q: %w", name, err)
+		}
+	}
+
+	if len(ic.Exemplars) == 0 {
+		return nil
+	}
+
+	exemplarsDir := filepath.Join(dir, "exemplars")
+
+	err := os.MkdirAll(exemplarsDir, 0o700)
+	if err != nil {
+		return fmt.Errorf("create exemplars directory: %w", err)
+	}
+
+	for _, doc := range ic.Exemplars {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal exemplar %q: %w", doc.URI, err)
+		}
+
+		name := unsafeFilenameChars.ReplaceAllString(doc.URI, "_") + ".json"
+
+		err = os.WriteFile(filepath.Join(exemplarsDir, name), data, 0o600)
+		if err != nil {
+			return fmt.Errorf("write exemplar %q: %w", doc.URI, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package eleconf_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/eleconf"
+	"github.com/ttab/elephant-api/repository"
+	"github.com/twitchtv/twirp"
+)
+
+// fakeRepository is the state of an in-memory stand-in for the repository
+// API, see fakeClients.
+type fakeRepository struct {
+	types     []string
+	statuses  map[string][]string
+	workflows map[string]*repository.DocumentWorkflow
+	configs   map[string]*repository.TypeConfiguration
+	metaTypes []*repository.MetaTypeInfo
+	kinds     []*repository.MetricKind
+	active    []*repository.Schema
+	// generation and exemplars are the active schema generation and
+	// its exemplars.
+	generation int64
+	exemplars  []*repository.Exemplar
+}
+
+// fakeClients returns clients that implement the read methods used to
+// calculate changes.
+func fakeClients(repo *fakeRepository) *eleconf.StaticClients {
+	return &eleconf.StaticClients{
+		Workflows: fakeWorkflows{repo: repo},
+		Schemas:   fakeSchemas{repo: repo},
+		Metrics:   fakeMetrics{repo: repo},
+	}
+}
+
+// fakePlan plans the changes for the configuration against a fresh fake
+// repository.
+func fakePlan(
+	t *testing.T, conf *eleconf.Config, schemas []eleconf.LoadedSchema,
+) *eleconf.Plan {
+	t.Helper()
+
+	plan, err := eleconf.PlanChanges(t.Context(),
+		fakeClients(newFakeRepository()), conf, schemas, nil,
+		repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		t.Fatalf("plan changes: %v", err)
+	}
+
+	return plan
+}
+
+// describeChanges returns the operation and description of each change.
+func describeChanges(changes []eleconf.ConfigurationChange) []string {
+	var list []string
+
+	for _, c := range changes {
+		op, desc := c.Describe()
+
+		list = append(list, string(op)+" "+desc)
+	}
+
+	return list
+}
+
+type fakeWorkflows struct {
+	repository.Workflows
+
+	repo *fakeRepository
+}
+
+type fakeSchemas struct {
+	repository.Schemas
+
+	repo *fakeRepository
+}
+
+type fakeMetrics struct {
+	repository.Metrics
+
+	repo *fakeRepository
+}
+
+func (f fakeSchemas) GetDocumentTypes(
+	_ context.Context, _ *repository.GetDocumentTypesRequest,
+) (*repository.GetDocumentTypesResponse, error) {
+	return &repository.GetDocumentTypesResponse{Types: f.repo.types}, nil
+}
+
+func (f fakeWorkflows) GetStatuses(
+	_ context.Context, req *repository.GetStatusesRequest,
+) (*repository.GetStatusesResponse, error) {
+	var res repository.GetStatusesResponse
+
+	for _, s := range f.repo.statuses[req.Type] {
+		res.Statuses = append(res.Statuses, &repository.WorkflowStatus{
+			Type: req.Type,
+			Name: s,
+		})
+	}
+
+	return &res, nil
+}
+
+func (f fakeWorkflows) GetWorkflow(
+	_ context.Context, req *repository.GetWorkflowRequest,
+) (*repository.GetWorkflowResponse, error) {
+	wf, ok := f.repo.workflows[req.Type]
+	if !ok {
+		return nil, twirp.NotFoundError("no workflow")
+	}
+
+	return &repository.GetWorkflowResponse{Workflow: wf}, nil
+}
+
+func (f fakeSchemas) GetTypeConfiguration(
+	_ context.Context, req *repository.GetTypeConfigurationRequest,
+) (*repository.GetTypeConfigurationResponse, error) {
+	tc, ok := f.repo.configs[req.Type]
+	if !ok {
+		return nil, twirp.NotFoundError("no configuration")
+	}
+
+	return &repository.GetTypeConfigurationResponse{Configuration: tc}, nil
+}
+
+func (f fakeSchemas) GetMetaTypes(
+	_ context.Context, _ *repository.GetMetaTypesRequest,
+) (*repository.GetMetaTypesResponse, error) {
+	return &repository.GetMetaTypesResponse{Types: f.repo.metaTypes}, nil
+}
+
+func (f fakeMetrics) GetKinds(
+	_ context.Context, _ *repository.GetMetricKindsRequest,
+) (*repository.GetMetricKindsResponse, error) {
+	return &repository.GetMetricKindsResponse{Kinds: f.repo.kinds}, nil
+}
+
+func (f fakeSchemas) ListActive(
+	_ context.Context, _ *repository.ListActiveSchemasRequest,
+) (*repository.ListActiveSchemasResponse, error) {
+	return &repository.ListActiveSchemasResponse{
+		Schemas:      f.repo.active,
+		GenerationId: f.repo.generation,
+	}, nil
+}
+
+func (f fakeSchemas) GetExemplars(
+	_ context.Context, _ *repository.GetExemplarsRequest,
+) (*repository.GetExemplarsResponse, error) {
+	return &repository.GetExemplarsResponse{Exemplars: f.repo.exemplars}, nil
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		types: []string{
+			"core/article", "core/article+meta", "core/author",
+			"core/unconfigured",
+		},
+		statuses: map[string][]string{
+			"core/article":          {"draft", "done", "usable"},
+			"core/article#timeless": {"usable"},
+			"core/author":           {"usable"},
+		},
+		workflows: map[string]*repository.DocumentWorkflow{
+			"core/article": {
+				StepZero:           "draft",
+				Checkpoint:         "usable",
+				NegativeCheckpoint: "unpublished",
+				Steps:              []string{"draft", "done"},
+			},
+		},
+		configs: map[string]*repository.TypeConfiguration{
+			"core/article": {
+				BoundedCollection: true,
+				Variants:          []string{"timeless"},
+				LabelExpressions: []*repository.LabelExpression{{
+					Expression: ".meta(type='core/section').data{code}",
+					Template:   "section-{{.code}}",
+				}},
+				EvictNoncurrentAfter: 30,
+			},
+		},
+		metaTypes: []*repository.MetaTypeInfo{{
+			Name:   "core/article+meta",
+			UsedBy: []string{"core/article"},
+		}},
+		kinds: []*repository.MetricKind{
+			{Name: "wordcount", Aggregation: repository.MetricAggregation_REPLACE},
+			{Name: "charcount", Aggregation: repository.MetricAggregation_INCREMENT},
+		},
+		active: []*repository.Schema{
+			{Name: "core-planning", Version: "v1.0.6"},
+			{Name: "core", Version: "v1.0.6"},
+			{Name: "tt", Version: "v1.0.5"},
+		},
+	}
+}
+
+func TestImportConfig_RoundTrip(t *testing.T) {
+	ctx := t.Context()
+	clients := fakeClients(newFakeRepository())
+
+	imported, err := eleconf.ImportConfig(ctx, clients,
+		"https://github.com/ttab/revisorschemas.git")
+	if err != nil {
+		t.Fatalf("import configuration: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	err = imported.Write(dir)
+	if err != nil {
+		t.Fatalf("write configuration: %v", err)
+	}
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read imported configuration: %v", err)
+	}
+
+	var types []string
+
+	for _, d := range conf.Documents {
+		types = append(types, d.Type)
+	}
+
+	if !slices.Equal(types, []string{
+		"core/article", "core/article#timeless", "core/author",
+	}) {
+		t.Errorf("unexpected imported document types: %v", types)
+	}
+
+	var sets []string
+
+	for _, s := range conf.SchemaSets {
+		sets = append(sets, s.Name+"@"+s.Version+":"+
+			strings.Join(s.Schemas, ","))
+	}
+
+	if !slices.Equal(sets, []string{
+		"core@v1.0.6:core,core-planning", "tt@v1.0.5:tt",
+	}) {
+		t.Errorf("unexpected imported schema sets: %v", sets)
+	}
+
+	for name, get := range map[string]func(
+		context.Context, eleconf.Clients, *eleconf.Config,
+	) ([]eleconf.ConfigurationChange, error){
+		"status":        eleconf.GetStatusChanges,
+		"workflow":      eleconf.GetWorkflowChanges,
+		"meta type":     eleconf.GetMetaTypeChanges,
+		"metric":        eleconf.GetMetricsChanges,
+		"type settings": eleconf.GetTypeConfigurationChanges,
+	} {
+		changes, err := get(ctx, clients, conf)
+		if err != nil {
+			t.Fatalf("get %s changes: %v", name, err)
+		}
+
+		for _, c := range changes {
+			_, desc := c.Describe()
+
+			t.Errorf("unexpected %s change after import: %s", name, desc)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "documents.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `evict_noncurrent_after = "30d"`) {
+		t.Errorf("expected the eviction period in days, got:\n%s", data)
+	}
+}
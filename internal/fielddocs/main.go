@@ -0,0 +1,189 @@
+// Command fielddocs generates the descriptions of the configuration
+// attributes and blocks for the JSON schema from the doc comments of the
+// struct fields. It's run through go generate in the eleconf package.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func main() {
+	out := flag.String("out", "", "file to write the descriptions to")
+
+	flag.Parse()
+
+	err := run(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fielddocs: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(out string) error {
+	if out == "" {
+		return errors.New("no output file given")
+	}
+
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		return fmt.Errorf("list source files: %w", err)
+	}
+
+	var (
+		pkg  string
+		docs = make(map[string]string)
+	)
+
+	fset := token.NewFileSet()
+
+	for _, p := range paths {
+		if strings.HasSuffix(p, "_test.go") || p == out {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, p, nil, parser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("parse %q: %w", p, err)
+		}
+
+		pkg = f.Name.Name
+
+		err = collectDocs(f, docs)
+		if err != nil {
+			return fmt.Errorf("collect descriptions from %q: %w", p, err)
+		}
+	}
+
+	keys := make([]string, 0, len(docs))
+
+	for k := range docs {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by fielddocs. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "// fieldDescriptions documents the configuration attributes and blocks,\n")
+	fmt.Fprintf(&buf, "// keyed by \"<struct>.<field>\".\n")
+	fmt.Fprintf(&buf, "var fieldDescriptions = map[string]string{\n")
+
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "\t%q: %s,\n", k, strconv.Quote(docs[k]))
+	}
+
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format output: %w", err)
+	}
+
+	err = os.WriteFile(out, src, 0o600)
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	return nil
+}
+
+// collectDocs adds the descriptions of the attribute and block fields of the
+// structs in a file.
+func collectDocs(f *ast.File, docs map[string]string) error {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 0 || !isConfigField(field) {
+					continue
+				}
+
+				name := field.Names[0].Name
+
+				if field.Doc == nil {
+					return fmt.Errorf("%s.%s has no doc comment",
+						ts.Name.Name, name)
+				}
+
+				docs[ts.Name.Name+"."+name] = describe(
+					name, field.Doc.Text())
+			}
+		}
+	}
+
+	return nil
+}
+
+// isConfigField checks if a field is decoded as an attribute or block.
+func isConfigField(field *ast.Field) bool {
+	if field.Tag == nil {
+		return false
+	}
+
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return false
+	}
+
+	tag := reflect.StructTag(raw)
+
+	value, ok := tag.Lookup("hcl")
+	if !ok {
+		value, ok = tag.Lookup("cty")
+	}
+
+	name, kind, _ := strings.Cut(value, ",")
+
+	return ok && name != "" && kind != "label"
+}
+
+// describe turns a doc comment into a description. The comment is joined to
+// a single line, and a leading field name, followed by "is" or "are", is
+// dropped, so that "Statuses are the statuses..." becomes "The statuses...".
+func describe(name string, doc string) string {
+	text := strings.Join(strings.Fields(doc), " ")
+
+	rest, ok := strings.CutPrefix(text, name+" ")
+	if !ok {
+		return text
+	}
+
+	for _, verb := range []string{"is ", "are "} {
+		rest = strings.TrimPrefix(rest, verb)
+	}
+
+	first, size := utf8.DecodeRuneInString(rest)
+
+	return string(unicode.ToUpper(first)) + rest[size:]
+}
@@ -0,0 +1,256 @@
+package eleconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The descriptions of attributes and blocks are generated from the doc
+// comments of the configuration struct fields.
+//go:generate go run ./internal/fielddocs -out jsonschema_docs.go
+
+// JSONSchema is a JSON Schema (draft 2020-12) subset, enough to describe the
+// configuration for editors.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+}
+
+// documentSet is only used to describe "documents" blocks, they are decoded
+// by expandDocumentSets.
+type documentSet struct {
+	Name string `hcl:"name,label"`
+	// ForEach is a list, set or map. The document block is repeated for each
+	// element, with each.key and each.value available to expressions.
+	ForEach any `hcl:"for_each"`
+	// Type is the document type, defaults to each.key.
+	Type string `hcl:"type,optional"`
+
+	DocumentConfig
+}
+
+// configRoot describes the top level of a configuration file, including the
+// blocks that are handled before the Config is decoded.
+type configRoot struct {
+	// Include lists files or directories to read configuration from,
+	// relative to this file.
+	Include []string `hcl:"include,optional"`
+	// Variable declares an input variable, set with --var or
+	// ELECONF_VAR_<name>. Available to expressions as var.<name>.
+	Variable []Variable `hcl:"variable,block"`
+	// Locals are local values, available to expressions as local.<name>.
+	Locals map[string]any `hcl:"locals,block"`
+	// Sets declares a set of document types, one for each element in
+	// for_each.
+	Sets []documentSet `hcl:"documents,block"`
+	// Remove removes blocks from the base configuration, only allowed in
+	// environment overlays.
+	Remove []overlayRemoval `hcl:"remove,block"`
+	// Extend adds statuses, expressions and attachments to a document type
+	// declared in another block.
+	Extend []documentExtension `hcl:"extend,block"`
+	// Defaults are default settings for the document types that match a
+	// pattern, f.ex. "tt/*".
+	Defaults []documentDefaults `hcl:"document_defaults,block"`
+
+	Config
+}
+
+// fieldEnums lists the allowed values of attributes.
+var fieldEnums = map[string][]string{
+	"MetricKind.Aggregation": {
+		string(MetricAggregationReplace),
+		string(MetricAggregationIncrement),
+	},
+}
+
+// ConfigJSONSchema describes the configuration files as a JSON Schema. The
+// schema is generated from the struct tags of the configuration types and
+// describes the HCL JSON syntax, but block and attribute names are the same
+// in native HCL syntax.
+func ConfigJSONSchema() *JSONSchema {
+	s := structSchema(reflect.TypeFor[configRoot]())
+
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.Title = "eleconf configuration"
+
+	return s
+}
+
+func structSchema(t reflect.Type) *JSONSchema {
+	s := JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: false,
+	}
+
+	for _, f := range structFields(t) {
+		key := f.owner.Name() + "." + f.Name
+
+		var prop *JSONSchema
+
+		switch f.kind {
+		case "block":
+			prop = blockSchema(f.Type)
+		default:
+			prop = valueSchema(f.Type)
+			prop.Enum = fieldEnums[key]
+			prop = allowExpression(prop)
+
+			if f.kind != "optional" {
+				s.Required = append(s.Required, f.name)
+			}
+		}
+
+		prop.Description = fieldDescriptions[key]
+
+		s.Properties[f.name] = prop
+	}
+
+	return &s
+}
+
+type schemaField struct {
+	reflect.StructField
+
+	owner reflect.Type
+	name  string
+	kind  string
+}
+
+// structFields returns the attribute and block fields of a struct, including
+// the fields of embedded structs.
+func structFields(t reflect.Type) []schemaField {
+	var fields []schemaField
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			fields = append(fields, structFields(f.Type)...)
+
+			continue
+		}
+
+		tag := f.Tag.Get("hcl")
+		if tag == "" {
+			tag = f.Tag.Get("cty")
+		}
+
+		name, kind, _ := strings.Cut(tag, ",")
+
+		if name == "" || kind == "label" {
+			continue
+		}
+
+		fields = append(fields, schemaField{
+			StructField: f,
+			owner:       t,
+			name:        name,
+			kind:        kind,
+		})
+	}
+
+	return fields
+}
+
+// blockSchema describes a block. In HCL JSON syntax labelled blocks are
+// objects keyed by label, and repeated blocks can be given as an array.
+func blockSchema(t reflect.Type) *JSONSchema {
+	repeated := t.Kind() == reflect.Slice
+
+	if repeated || t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Map {
+		return &JSONSchema{
+			Type:                 "object",
+			AdditionalProperties: true,
+		}
+	}
+
+	body := structSchema(t)
+
+	if hasLabel(t) {
+		return &JSONSchema{
+			Type:                 "object",
+			AdditionalProperties: body,
+		}
+	}
+
+	if !repeated {
+		return body
+	}
+
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{
+			body,
+			{Type: "array", Items: body},
+		},
+	}
+}
+
+func hasLabel(t reflect.Type) bool {
+	for i := range t.NumField() {
+		if strings.HasSuffix(t.Field(i).Tag.Get("hcl"), ",label") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expressionPattern matches strings with template interpolations or
+// directives.
+const expressionPattern = `[$%]\{`
+
+// allowExpression lets an attribute that takes other values than free form
+// strings be set with a template in HCL JSON syntax, f.ex.
+// "${local.statuses}".
+func allowExpression(s *JSONSchema) *JSONSchema {
+	if s.Type == "" || (s.Type == "string" && s.Enum == nil) {
+		return s
+	}
+
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{
+			s,
+			{Type: "string", Pattern: expressionPattern},
+		},
+	}
+}
+
+func valueSchema(t reflect.Type) *JSONSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Slice:
+		return &JSONSchema{Type: "array", Items: valueSchema(t.Elem())}
+	case reflect.Pointer:
+		return valueSchema(t.Elem())
+	case reflect.Struct:
+		// cty.Value and other opaque values accept anything.
+		if len(structFields(t)) == 0 {
+			return &JSONSchema{}
+		}
+
+		return structSchema(t)
+	case reflect.Interface:
+		return &JSONSchema{}
+	default:
+		panic(fmt.Sprintf("no JSON schema for %s", t))
+	}
+}
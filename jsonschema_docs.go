@@ -0,0 +1,77 @@
+// Code generated by fielddocs. DO NOT EDIT.
+
+package eleconf
+
+// fieldDescriptions documents the configuration attributes and blocks,
+// keyed by "<struct>.<field>".
+var fieldDescriptions = map[string]string{
+	"AttachmentConfig.MatchMimetype":         "Lists the accepted mimetypes of the attachment.",
+	"AttachmentConfig.Required":              "Controls whether documents must have the attachment.",
+	"Config.Documents":                       "The configured document types.",
+	"Config.Manage":                          "Limits what eleconf manages in the repository, changes outside of the scope are reported as drift.",
+	"Config.Metric":                          "Declares the metric kinds.",
+	"Config.SchemaSets":                      "Sets of revisor schemas loaded from a git repository or URL.",
+	"Config.WorkflowProfiles":                "Statuses and workflows that documents can use through their profile attribute.",
+	"DocumentConfig.AddStatuses":             "Statuses to add to the ones from the profile.",
+	"DocumentConfig.Attachments":             "The attachments that documents of this type can have.",
+	"DocumentConfig.BoundedCollection":       "Marks the type as a bounded collection, which doesn't grow indefinitely and can be listed in full.",
+	"DocumentConfig.DropStatuses":            "Statuses to remove from the ones from the profile, they are also removed from the workflow steps.",
+	"DocumentConfig.EvictNoncurrentAfter":    "The period after which non-current document versions are evicted, f.ex. \"720h\" or \"30d\". Must be a whole number of days.",
+	"DocumentConfig.LabelExpressions":        "Expressions that extract labels for documents of this type.",
+	"DocumentConfig.MetaDocType":             "The meta document type for documents of this type.",
+	"DocumentConfig.Profile":                 "The workflow profile to take statuses and workflow from.",
+	"DocumentConfig.Statuses":                "The statuses that documents of this type can have.",
+	"DocumentConfig.TimeExpressions":         "Expressions that extract the timestamps or dates that documents of this type cover.",
+	"DocumentConfig.Variants":                "Variants of the document type, declared as \"<type>#<variant>\".",
+	"DocumentConfig.Workflow":                "The document workflow.",
+	"DocumentWorkflow.Checkpoint":            "The status that completes the workflow, usually \"usable\".",
+	"DocumentWorkflow.NegativeCheckpoint":    "The workflow state of a document that has been withdrawn after the checkpoint.",
+	"DocumentWorkflow.StepZero":              "The status that a document starts with, and returns to after the checkpoint.",
+	"DocumentWorkflow.Steps":                 "The statuses that are workflow steps between step zero and the checkpoint.",
+	"LabelExpression.Expression":             "A newsdoc value extraction expression.",
+	"LabelExpression.Template":               "The template that turns the extracted values into a label.",
+	"ManageScope.Metrics":                    "Patterns for the metric kinds to manage, f.ex. \"kk_*\". All metric kinds are managed if not set.",
+	"ManageScope.Schemas":                    "Controls whether schema generations are managed, defaults to true.",
+	"ManageScope.Types":                      "Patterns for the document types to manage, f.ex. \"tt/*\". All types are managed if not set.",
+	"MetricKind.Aggregation":                 "How metric values are aggregated, \"replace\" (default) or \"increment\".",
+	"SchemaSet.Repository":                   "A git repository to load the schemas from.",
+	"SchemaSet.Schemas":                      "The names of the schemas in the set.",
+	"SchemaSet.URLTemplate":                  "A Go text template for the schema URLs, with .Name and .Version available.",
+	"SchemaSet.Version":                      "The version to load, a tag in the repository.",
+	"TimeExpression.Expression":              "A newsdoc value extraction expression.",
+	"TimeExpression.Layout":                  "The time/date format to use when parsing. Optional, defaults to RFC3339 or ISO 8601 for values annotated as dates.",
+	"TimeExpression.Timezone":                "The timezone the time should be parsed in. Optional, most timestamps should include timezone information, if they don't, parsing will fall back to the default timezone that the repository has been configured with.",
+	"Variable.Default":                       "The default value, the variable is required if there is none.",
+	"Variable.Description":                   "Describes the variable.",
+	"WorkflowProfile.Statuses":               "The statuses of documents using the profile.",
+	"WorkflowProfile.Workflow":               "The workflow of documents using the profile.",
+	"configRoot.Defaults":                    "Default settings for the document types that match a pattern, f.ex. \"tt/*\".",
+	"configRoot.Extend":                      "Adds statuses, expressions and attachments to a document type declared in another block.",
+	"configRoot.Include":                     "Lists files or directories to read configuration from, relative to this file.",
+	"configRoot.Locals":                      "Local values, available to expressions as local.<name>.",
+	"configRoot.Remove":                      "Removes blocks from the base configuration, only allowed in environment overlays.",
+	"configRoot.Sets":                        "Declares a set of document types, one for each element in for_each.",
+	"configRoot.Variable":                    "Declares an input variable, set with --var or ELECONF_VAR_<name>. Available to expressions as var.<name>.",
+	"documentDefaults.Attachments":           "The default attachments, used if the document declares none.",
+	"documentDefaults.BoundedCollection":     "Makes the matching documents bounded collections.",
+	"documentDefaults.EvictNoncurrentAfter":  "The default eviction period.",
+	"documentDefaults.LabelExpressions":      "The default label expressions, used if the document declares none.",
+	"documentDefaults.Profile":               "The default workflow profile.",
+	"documentDefaults.SchemaTypes":           "Adds documents for the matching types declared in the schemas that haven't been configured.",
+	"documentDefaults.Statuses":              "The default statuses, not used for documents with a profile.",
+	"documentDefaults.TimeExpressions":       "The default time expressions, used if the document declares none.",
+	"documentDefaults.Workflow":              "The default workflow, not used for documents with a profile.",
+	"documentExtension.Attachments":          "Attachments to add to the document type.",
+	"documentExtension.BoundedCollection":    "Sets whether the document type is a bounded collection, if not set by the document or another extension.",
+	"documentExtension.EvictNoncurrentAfter": "The eviction period, if not set by the document or another extension.",
+	"documentExtension.LabelExpressions":     "Label expressions to add to the document type.",
+	"documentExtension.MetaDocType":          "The meta document type, if not set by the document or another extension.",
+	"documentExtension.Statuses":             "Statuses to add to the document type.",
+	"documentExtension.TimeExpressions":      "Time expressions to add to the document type.",
+	"documentExtension.Variants":             "Variants to add to the document type.",
+	"documentSet.ForEach":                    "A list, set or map. The document block is repeated for each element, with each.key and each.value available to expressions.",
+	"documentSet.Type":                       "The document type, defaults to each.key.",
+	"overlayRemoval.Documents":               "The document types to remove.",
+	"overlayRemoval.Metrics":                 "The metric kinds to remove.",
+	"overlayRemoval.SchemaSets":              "The schema sets to remove.",
+}
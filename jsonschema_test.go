@@ -0,0 +1,72 @@
+package eleconf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+func TestConfigJSONSchema(t *testing.T) {
+	s := eleconf.ConfigJSONSchema()
+
+	var walk func(path string, s *eleconf.JSONSchema)
+
+	walk = func(path string, s *eleconf.JSONSchema) {
+		for name, prop := range s.Properties {
+			if prop.Description == "" {
+				t.Errorf("%s.%s has no description", path, name)
+			}
+
+			walk(path+"."+name, prop)
+		}
+
+		if body, ok := s.AdditionalProperties.(*eleconf.JSONSchema); ok {
+			walk(path+".*", body)
+		}
+
+		if s.Items != nil {
+			walk(path+"[]", s.Items)
+		}
+
+		for _, alt := range s.AnyOf {
+			walk(path, alt)
+		}
+	}
+
+	walk("root", s)
+
+	doc, ok := s.Properties["document"].AdditionalProperties.(*eleconf.JSONSchema)
+	if !ok {
+		t.Fatal("expected documents to be keyed by type")
+	}
+
+	bounded := doc.Properties["bounded_collection"]
+
+	if len(bounded.AnyOf) != 2 ||
+		bounded.AnyOf[0].Type != "boolean" ||
+		bounded.AnyOf[1].Type != "string" {
+		t.Error("expected bounded_collection to be a boolean or an expression")
+	}
+
+	statuses := doc.Properties["statuses"]
+
+	if len(statuses.AnyOf) != 2 ||
+		statuses.AnyOf[0].Type != "array" ||
+		statuses.AnyOf[1].Pattern == "" {
+		t.Error("expected statuses to be an array or an expression")
+	}
+
+	if doc.Properties["profile"].Type != "string" {
+		t.Error("expected profile to be a string")
+	}
+
+	if doc.AdditionalProperties != false {
+		t.Error("expected unknown document attributes to be rejected")
+	}
+
+	_, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+}
@@ -0,0 +1,603 @@
+package eleconf
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ConfigError is returned when a configuration fails to load. It carries
+// source located diagnostics, and the parsed files so that the diagnostics
+// can be displayed together with the offending configuration.
+type ConfigError struct {
+	Diagnostics hcl.Diagnostics
+	Files       map[string]*hcl.File
+}
+
+func (e *ConfigError) Error() string {
+	return e.Diagnostics.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Diagnostics
+}
+
+// WriteText writes the diagnostics to w with source snippets. Lines are
+// wrapped at width, zero disables wrapping.
+func (e *ConfigError) WriteText(w io.Writer, width uint, color bool) error {
+	return WriteDiagnostics(w, e.Files, e.Diagnostics, width, color)
+}
+
+// WriteDiagnostics writes diagnostics to w with snippets from the given
+// files. Lines are wrapped at width, zero disables wrapping.
+func WriteDiagnostics(
+	w io.Writer,
+	files map[string]*hcl.File,
+	diags hcl.Diagnostics,
+	width uint, color bool,
+) error {
+	dw := hcl.NewDiagnosticTextWriter(w, files, width, color)
+
+	err := dw.WriteDiagnostics(diags)
+	if err != nil {
+		return fmt.Errorf("write diagnostics: %w", err)
+	}
+
+	return nil
+}
+
+// ConfigOptions control how configuration is loaded.
+type ConfigOptions struct {
+	// Variables are the input values for variables declared in the
+	// configuration. A value for an undeclared variable is an error.
+	Variables map[string]string
+	// EnvVariables are input values from the environment. They are used
+	// for variables that have no value in Variables, and values for
+	// undeclared variables are ignored.
+	EnvVariables map[string]string
+	// IgnoreUndeclared ignores values in Variables for undeclared
+	// variables, used when the same values are given to several
+	// configuration roots. See Config.DeclaredVariables.
+	IgnoreUndeclared bool
+	// Sources replace the contents of configuration files, keyed by the
+	// file name used in diagnostics. Used by editors to validate unsaved
+	// changes, the files must exist.
+	Sources map[string][]byte
+	// IncludeRoot is the directory that included files must be in,
+	// defaults to the configuration directory. Set it to a common parent
+	// to include files that are shared with other configuration
+	// directories. Not used by ReadConfigFS, where includes must be in
+	// the file system.
+	IncludeRoot string
+	// Environment selects the overlay directory in "environments/" to
+	// merge on top of the base configuration. No overlay is applied if
+	// the configuration has no "environments/" directory, otherwise an
+	// environment without an overlay directory is an error.
+	Environment string
+}
+
+// ReadConfigFromDirectory reads and merges all configuration files in a
+// directory. Configuration problems are returned as a *ConfigError.
+func ReadConfigFromDirectory(path string) (*Config, error) {
+	return ReadConfig(path, ConfigOptions{})
+}
+
+// ReadConfig reads and merges all configuration files in a directory and its
+// subdirectories using the given options. Files are read in lexical path
+// order, and files listed in an "include" attribute are read after the file
+// that includes them. Configuration problems are returned as a *ConfigError.
+func ReadConfig(path string, opts ConfigOptions) (*Config, error) {
+	cfs, err := osConfigFS(path, opts.IncludeRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return readConfig(cfs, opts)
+}
+
+// ReadConfigFS reads and merges all configuration files in a file system,
+// like ReadConfig does for a directory. Diagnostics refer to files by their
+// path in fsys, and includes must resolve to files in fsys.
+func ReadConfigFS(fsys fs.FS, opts ConfigOptions) (*Config, error) {
+	return readConfig(&configFS{fsys: fsys, dir: "."}, opts)
+}
+
+func readConfig(cfs *configFS, opts ConfigOptions) (*Config, error) {
+	parser := hclparse.NewParser()
+	collector := newFileCollector(cfs, parser)
+
+	collector.sources = opts.Sources
+
+	err := collector.collectDir(cfs.dir, true)
+	if err != nil {
+		return nil, fmt.Errorf("read configuration files: %w", err)
+	}
+
+	base := collector.take()
+
+	var overlay []hcl.Body
+
+	envDir, err := environmentDir(cfs.fsys, cfs.dir, opts.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if envDir != "" {
+		err := collector.collectDir(envDir, false)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"read %q environment: %w", opts.Environment, err)
+		}
+
+		overlay = collector.take()
+	}
+
+	diags := collector.diags
+
+	var conf *Config
+
+	// Don't bother decoding if the files themselves are broken.
+	if !diags.HasErrors() {
+		c, dDiags := decodeFiles(base, overlay, opts, cfs.name())
+
+		diags = append(diags, dDiags...)
+		conf = c
+	}
+
+	if diags.HasErrors() {
+		return nil, &ConfigError{
+			Diagnostics: diags,
+			Files:       parser.Files(),
+		}
+	}
+
+	conf.files = parser.Files()
+
+	return conf, nil
+}
+
+// EnvironmentDir returns the path of the overlay directory for an
+// environment.
+func EnvironmentDir(dir string, env string) string {
+	return filepath.Join(dir, "environments", env)
+}
+
+// variablesSchema describes the blocks that have to be evaluated before the
+// rest of the configuration can be decoded.
+var variablesSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "locals"},
+	},
+}
+
+// decodeFiles evaluates variables and locals across all file bodies, and
+// then decodes and merges the configuration blocks. The overlay bodies are
+// merged on top of the base configuration. Root names the configuration in
+// diagnostics that have no source range.
+func decodeFiles(
+	base []hcl.Body, overlay []hcl.Body, opts ConfigOptions, root string,
+) (*Config, hcl.Diagnostics) {
+	var (
+		diags     hcl.Diagnostics
+		variables []Variable
+		locals    []*hcl.Attribute
+		bodies    []hcl.Body
+	)
+
+	for _, body := range slices.Concat(base, overlay) {
+		content, remain, cDiags := body.PartialContent(variablesSchema)
+
+		diags = append(diags, cDiags...)
+
+		for _, block := range content.Blocks {
+			switch block.Type {
+			case "variable":
+				v := Variable{
+					Name:      block.Labels[0],
+					DeclRange: block.DefRange,
+				}
+
+				diags = append(diags, gohcl.DecodeBody(
+					block.Body, nil, &v)...)
+
+				variables = append(variables, v)
+			case "locals":
+				attrs, aDiags := block.Body.JustAttributes()
+
+				diags = append(diags, aDiags...)
+
+				for _, attr := range attrs {
+					locals = append(locals, attr)
+				}
+			}
+		}
+
+		bodies = append(bodies, remain)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// Keep the local declaration order stable for diagnostics, the map
+	// returned by JustAttributes has none.
+	slices.SortFunc(locals, func(a, b *hcl.Attribute) int {
+		return compareRanges(a.Range, b.Range)
+	})
+
+	varValues, vDiags := evalVariables(
+		variables, opts.Variables, opts.EnvVariables)
+
+	diags = append(diags, vDiags...)
+
+	if !opts.IgnoreUndeclared {
+		diags = append(diags, checkUndeclared(
+			variables, opts.Variables, root)...)
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(varValues),
+		},
+		Functions: configFunctions(),
+	}
+
+	localValues, lDiags := evalLocals(locals, ctx)
+
+	diags = append(diags, lDiags...)
+
+	ctx.Variables["local"] = cty.ObjectVal(localValues)
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	baseBodies := bodies[:len(base)]
+
+	extensions, eDiags := takeExtensions(baseBodies, ctx)
+
+	diags = append(diags, eDiags...)
+
+	defaults, dDiags := takeDefaults(baseBodies, ctx)
+
+	diags = append(diags, dDiags...)
+
+	tutti, mDiags := mergeBodies(baseBodies, ctx)
+
+	diags = append(diags, mDiags...)
+
+	var (
+		oConf       *Config
+		oExtensions []documentExtension
+		removals    []overlayRemoval
+	)
+
+	if len(overlay) > 0 {
+		overlayBodies := bodies[len(base):]
+
+		for i, body := range overlayBodies {
+			r, remain, rDiags := decodeRemovals(body)
+
+			diags = append(diags, rDiags...)
+			removals = append(removals, r...)
+			overlayBodies[i] = remain
+		}
+
+		exts, eDiags := takeExtensions(overlayBodies, ctx)
+
+		diags = append(diags, eDiags...)
+		oExtensions = exts
+
+		oDefaults, dDiags := takeDefaults(overlayBodies, ctx)
+
+		diags = append(diags, dDiags...)
+
+		defaults = overlayBlocks(defaults, oDefaults,
+			func(d documentDefaults) string { return d.Pattern })
+
+		c, oDiags := mergeBodies(overlayBodies, ctx)
+
+		diags = append(diags, oDiags...)
+
+		// Duplicates have to be caught before the overlay is
+		// applied, as it replaces blocks by name.
+		diags = append(diags, checkDuplicates(c)...)
+
+		oConf = c
+	}
+
+	// Defaults are applied before extensions, so that the lists of an
+	// extension add to the defaults, and its settings replace them.
+	// Extensions in the base configuration are applied before the
+	// overlay, as overlay blocks replace the extended documents.
+	if !diags.HasErrors() {
+		defaulted := make(map[string]bool)
+
+		fillDefaults(tutti, defaults, defaulted)
+
+		diags = append(diags, applyExtensions(
+			tutti, extensions, defaulted)...)
+
+		if oConf != nil && !diags.HasErrors() {
+			fillDefaults(oConf, defaults, defaulted)
+
+			diags = append(diags, applyOverlay(
+				tutti, oConf, removals)...)
+		}
+
+		if !diags.HasErrors() {
+			diags = append(diags, applyExtensions(
+				tutti, oExtensions, defaulted)...)
+		}
+	}
+
+	// Don't bother with cross file checks if the files themselves are
+	// broken.
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	tutti.defaults = defaults
+
+	for _, v := range variables {
+		tutti.variables = append(tutti.variables, v.Name)
+	}
+
+	diags = append(diags, resolveProfiles(tutti)...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	diags = append(diags, checkConfig(tutti)...)
+	diags = append(diags, checkScope(tutti)...)
+
+	return tutti, diags
+}
+
+// mergeBodies decodes the bodies and concatenates the results.
+func mergeBodies(
+	bodies []hcl.Body, ctx *hcl.EvalContext,
+) (*Config, hcl.Diagnostics) {
+	var (
+		tutti Config
+		diags hcl.Diagnostics
+	)
+
+	for _, body := range bodies {
+		c, fDiags := decodeBody(body, ctx)
+
+		diags = append(diags, fDiags...)
+
+		if c == nil {
+			continue
+		}
+
+		tutti.SchemaSets = append(tutti.SchemaSets, c.SchemaSets...)
+		tutti.Documents = append(tutti.Documents, c.Documents...)
+		tutti.Metric = append(tutti.Metric, c.Metric...)
+		tutti.WorkflowProfiles = append(
+			tutti.WorkflowProfiles, c.WorkflowProfiles...)
+
+		switch {
+		case c.Manage == nil:
+		case tutti.Manage != nil:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate manage block",
+				Detail: fmt.Sprintf(
+					"The manage block was already defined at %s.",
+					tutti.Manage.DeclRange),
+				Subject: c.Manage.DeclRange.Ptr(),
+			})
+		default:
+			tutti.Manage = c.Manage
+		}
+	}
+
+	return &tutti, diags
+}
+
+func compareRanges(a, b hcl.Range) int {
+	if a.Filename != b.Filename {
+		return strings.Compare(a.Filename, b.Filename)
+	}
+
+	return a.Start.Byte - b.Start.Byte
+}
+
+func decodeBody(body hcl.Body, ctx *hcl.EvalContext) (*Config, hcl.Diagnostics) {
+	var c Config
+
+	generated, body, diags := expandDocumentSets(body, ctx)
+
+	diags = append(diags, gohcl.DecodeBody(body, ctx, &c)...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	c.Documents = append(c.Documents, generated...)
+
+	for _, doc := range c.Documents {
+		_, err := doc.EvictionPeriod()
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid evict_noncurrent_after",
+				Detail: fmt.Sprintf(
+					"Invalid evict_noncurrent_after for %q: %v.",
+					doc.Type, err),
+				Subject: doc.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	for _, m := range c.Metric {
+		switch m.Aggregation {
+		case "", MetricAggregationIncrement, MetricAggregationReplace:
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown metric aggregation",
+				Detail: fmt.Sprintf(
+					"Unknown %q metric aggregation %q, must be %q or %q.",
+					m.Kind, m.Aggregation,
+					MetricAggregationReplace,
+					MetricAggregationIncrement),
+				Subject: m.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	return &c, diags
+}
+
+// checkConfig runs the checks that need to see the merged configuration.
+func checkConfig(conf *Config) hcl.Diagnostics {
+	diags := checkDuplicates(conf)
+
+	docs := make(map[string]*DocumentConfig, len(conf.Documents))
+
+	for i := range conf.Documents {
+		doc := &conf.Documents[i]
+
+		_, dup := docs[doc.Type]
+		if !dup {
+			docs[doc.Type] = doc
+		}
+	}
+
+	for _, doc := range conf.Documents {
+		base, variant := ParseDocumentType(doc.Type)
+		if variant == "" {
+			continue
+		}
+
+		if len(doc.Variants) > 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Variant defines variants",
+				Detail: fmt.Sprintf(
+					"The variant document %q must not define variants.",
+					doc.Type),
+				Subject: doc.DeclRange.Ptr(),
+			})
+		}
+
+		baseDoc, ok := docs[base]
+		if !ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Undefined base type",
+				Detail: fmt.Sprintf(
+					"The base type %q of the variant document %q has not been defined.",
+					base, doc.Type),
+				Subject: doc.DeclRange.Ptr(),
+			})
+
+			continue
+		}
+
+		declared := false
+
+		for _, v := range baseDoc.Variants {
+			declared = declared || v == variant
+		}
+
+		if !declared {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Undeclared variant",
+				Detail: fmt.Sprintf(
+					"The variant %q has not been declared for %q at %s.",
+					variant, base, baseDoc.DeclRange),
+				Subject: doc.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// checkDuplicates checks that documents, schema sets and metric kinds only
+// are declared once.
+func checkDuplicates(conf *Config) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	docs := make(map[string]hcl.Range, len(conf.Documents))
+
+	for _, doc := range conf.Documents {
+		first, dup := docs[doc.Type]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"document type", doc.Type, first, doc.DeclRange)...)
+
+			continue
+		}
+
+		docs[doc.Type] = doc.DeclRange
+	}
+
+	sets := make(map[string]hcl.Range, len(conf.SchemaSets))
+
+	for _, set := range conf.SchemaSets {
+		first, dup := sets[set.Name]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"schema set", set.Name, first, set.DeclRange)...)
+
+			continue
+		}
+
+		sets[set.Name] = set.DeclRange
+	}
+
+	kinds := make(map[string]hcl.Range, len(conf.Metric))
+
+	for _, m := range conf.Metric {
+		first, dup := kinds[m.Kind]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"metric kind", m.Kind, first, m.DeclRange)...)
+
+			continue
+		}
+
+		kinds[m.Kind] = m.DeclRange
+	}
+
+	return diags
+}
+
+// duplicateDiags reports a duplicate definition, both at the duplicate and at
+// the first definition, so that editors can show and navigate to both.
+func duplicateDiags(
+	what string, name string, first hcl.Range, dup hcl.Range,
+) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate " + what,
+			Detail: fmt.Sprintf(
+				"The %s %q was already defined at %s.",
+				what, name, first),
+			Subject: dup.Ptr(),
+		},
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate " + what,
+			Detail: fmt.Sprintf(
+				"The %s %q is defined again at %s.",
+				what, name, dup),
+			Subject: first.Ptr(),
+		},
+	}
+}
@@ -3,7 +3,9 @@ package eleconf
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -26,7 +28,13 @@ func NewSchemaLockFile(
 
 // LoadLockFile reads and parses a lockfile from disk.
 func LoadLockFile(fileName string) (*SchemaLockfile, error) {
-	data, err := os.ReadFile(fileName)
+	return LoadLockFileFS(
+		os.DirFS(filepath.Dir(fileName)), filepath.Base(fileName))
+}
+
+// LoadLockFileFS reads and parses a lockfile from a file system.
+func LoadLockFileFS(fsys fs.FS, name string) (*SchemaLockfile, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("read lock file: %w", err)
 	}
@@ -56,6 +64,9 @@ func (lf *SchemaLockfile) Save(fileName string) error {
 		return fmt.Errorf("marshal lock data: %w", err)
 	}
 
+	// End with a newline, like an editor would.
+	data = append(data, '\n')
+
 	err = os.WriteFile(fileName, data, 0o600)
 	if err != nil {
 		return fmt.Errorf("write to file: %w", err)
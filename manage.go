@@ -0,0 +1,344 @@
+package eleconf
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/ttab/elephant-api/repository"
+)
+
+// ManageScope limits what eleconf manages in the repository, so that it can
+// share a repository with other tools or configurations. Types and Metrics
+// are lists of path.Match patterns, f.ex. "tt/*" or "kk_*". A list that
+// hasn't been set manages everything. Schemas can be set to false to leave
+// schema generations alone.
+type ManageScope struct {
+	// Types are patterns for the document types to manage, f.ex. "tt/*". All
+	// types are managed if not set.
+	Types []string `hcl:"types,optional" json:"types,omitempty"`
+	// Metrics are patterns for the metric kinds to manage, f.ex. "kk_*". All
+	// metric kinds are managed if not set.
+	Metrics []string `hcl:"metrics,optional" json:"metrics,omitempty"`
+	// Schemas controls whether schema generations are managed, defaults to
+	// true.
+	Schemas   *bool     `hcl:"schemas,optional" json:"schemas,omitempty"`
+	DeclRange hcl.Range `hcl:",def_range" json:"-"`
+}
+
+// ManagesType checks if a document type is in scope. Variants are in scope
+// if their base type is.
+func (s *ManageScope) ManagesType(docType string) bool {
+	if s == nil || s.Types == nil {
+		return true
+	}
+
+	base, _ := ParseDocumentType(docType)
+
+	return matchAny(s.Types, base)
+}
+
+// ManagesMetric checks if a metric kind is in scope.
+func (s *ManageScope) ManagesMetric(kind string) bool {
+	if s == nil || s.Metrics == nil {
+		return true
+	}
+
+	return matchAny(s.Metrics, kind)
+}
+
+// ManagesSchemas checks if schema generations are in scope.
+func (s *ManageScope) ManagesSchemas() bool {
+	return s == nil || s.Schemas == nil || *s.Schemas
+}
+
+// Manages checks if the target of a change is in scope. A target without a
+// name covers its whole domain, and is only in scope if the domain isn't
+// limited. A target without a kind is always in scope, and a target of an
+// unknown kind never is, so that the change is reported as drift.
+func (s *ManageScope) Manages(t ChangeTarget) bool {
+	switch t.Kind {
+	case TargetSchemas:
+		return s.ManagesSchemas()
+	case TargetDocumentType:
+		if t.Name == "" {
+			return s == nil || s.Types == nil
+		}
+
+		return s.ManagesType(t.Name)
+	case TargetMetric:
+		if t.Name == "" {
+			return s == nil || s.Metrics == nil
+		}
+
+		return s.ManagesMetric(t.Name)
+	case "":
+		return true
+	default:
+		return false
+	}
+}
+
+// patternsOverlap checks if there is a name that is matched by a pattern
+// in both lists. A nil list matches everything.
+func patternsOverlap(a []string, b []string) bool {
+	switch {
+	case a == nil:
+		return b == nil || len(b) > 0
+	case b == nil:
+		return len(a) > 0
+	}
+
+	for _, p := range a {
+		for _, q := range b {
+			if globsOverlap(p, q) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// globsOverlap checks if there is a name that is matched by both path.Match
+// patterns. It walks both patterns at once, trying every character that can
+// make a difference: the ones in the patterns, their neighbours, a slash,
+// and one that isn't mentioned at all.
+func globsOverlap(p string, q string) bool {
+	pt, qt := globTokens(p), globTokens(q)
+
+	var candidates []rune
+
+	for _, r := range p + q {
+		candidates = append(candidates, r-1, r, r+1)
+	}
+
+	candidates = append(candidates, '/', '\uE000')
+
+	visited := make(map[[2]int]bool)
+
+	var walk func(i, j int) bool
+
+	walk = func(i, j int) bool {
+		if visited[[2]int{i, j}] {
+			return false
+		}
+
+		visited[[2]int{i, j}] = true
+
+		if i == len(pt) && j == len(qt) {
+			return true
+		}
+
+		if i < len(pt) && pt[i] == "*" && walk(i+1, j) {
+			return true
+		}
+
+		if j < len(qt) && qt[j] == "*" && walk(i, j+1) {
+			return true
+		}
+
+		if i == len(pt) || j == len(qt) {
+			return false
+		}
+
+		ni, nj := i, j
+
+		if pt[i] != "*" {
+			ni++
+		}
+
+		if qt[j] != "*" {
+			nj++
+		}
+
+		// Two stars matching the same character don't get anywhere.
+		if ni == i && nj == j {
+			return false
+		}
+
+		for _, c := range candidates {
+			if globAccepts(pt[i], c) && globAccepts(qt[j], c) &&
+				walk(ni, nj) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return walk(0, 0)
+}
+
+// globTokens splits a path.Match pattern into tokens that each match a
+// single character, except "*" that matches any number of them.
+func globTokens(pattern string) []string {
+	var tokens []string
+
+	runes := []rune(pattern)
+
+	for i := 0; i < len(runes); i++ {
+		start := i
+
+		switch runes[i] {
+		case '*':
+			if len(tokens) > 0 && tokens[len(tokens)-1] == "*" {
+				continue
+			}
+		case '\\':
+			i++
+		case '[':
+			for i < len(runes) && runes[i] != ']' {
+				if runes[i] == '\\' {
+					i++
+				}
+
+				i++
+			}
+		}
+
+		end := min(i+1, len(runes))
+
+		tokens = append(tokens, string(runes[start:end]))
+	}
+
+	return tokens
+}
+
+// globAccepts checks if a single pattern token matches a character.
+func globAccepts(token string, c rune) bool {
+	if token == "*" {
+		return c != '/'
+	}
+
+	ok, _ := path.Match(token, string(c))
+
+	return ok
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		ok, _ := path.Match(p, name)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkScope checks that the scope patterns are valid, and that everything
+// the configuration declares is in scope.
+func checkScope(conf *Config) hcl.Diagnostics {
+	s := conf.Manage
+	if s == nil {
+		return nil
+	}
+
+	var diags hcl.Diagnostics
+
+	for _, p := range append(s.Types, s.Metrics...) {
+		_, err := path.Match(p, "")
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid manage pattern",
+				Detail: fmt.Sprintf(
+					"The pattern %q is invalid: %v.", p, err),
+				Subject: s.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, doc := range conf.Documents {
+		for _, t := range []string{doc.Type, doc.MetaDocType} {
+			if t == "" || s.ManagesType(t) {
+				continue
+			}
+
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Document type outside of managed scope",
+				Detail: fmt.Sprintf(
+					"The document type %q isn't covered by the manage block at %s.",
+					t, s.DeclRange),
+				Subject: doc.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	for _, m := range conf.Metric {
+		if s.ManagesMetric(m.Kind) {
+			continue
+		}
+
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Metric kind outside of managed scope",
+			Detail: fmt.Sprintf(
+				"The metric kind %q isn't covered by the manage block at %s.",
+				m.Kind, s.DeclRange),
+			Subject: m.DeclRange.Ptr(),
+		})
+	}
+
+	if len(conf.SchemaSets) > 0 && !s.ManagesSchemas() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Schema sets outside of managed scope",
+			Detail: fmt.Sprintf(
+				"Schema sets are declared, but the manage block at %s excludes schemas.",
+				s.DeclRange),
+			Subject: conf.SchemaSets[0].DeclRange.Ptr(),
+		})
+	}
+
+	return diags
+}
+
+// Plan is a set of changes split by the managed scope of the configuration.
+// Drift are differences outside of the scope, they are informational and
+// never applied.
+type Plan struct {
+	Changes []ConfigurationChange
+	Drift   []ConfigurationChange
+}
+
+// NewPlan splits the changes by the managed scope of the configuration.
+func NewPlan(conf *Config, changes []ConfigurationChange) *Plan {
+	var p Plan
+
+	for _, c := range changes {
+		if conf.Manage.Manages(ChangeTargetOf(c)) {
+			p.Changes = append(p.Changes, c)
+		} else {
+			p.Drift = append(p.Drift, c)
+		}
+	}
+
+	return &p
+}
+
+// PlanChanges computes all configuration changes like GetChanges, and splits
+// them by the managed scope of the configuration.
+func PlanChanges(
+	ctx context.Context,
+	clients Clients,
+	conf *Config,
+	schemas []LoadedSchema,
+	exemplars []LoadedExemplar,
+	activation repository.SchemaActivation,
+) (*Plan, error) {
+	changes, err := GetChanges(
+		ctx, clients, conf, schemas, exemplars, activation)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPlan(conf, changes), nil
+}
@@ -0,0 +1,251 @@
+package eleconf_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+func TestPlanChanges_ManagedScope(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "config.hcl", `
+manage {
+  types   = ["core/article*"]
+  metrics = ["wordcount"]
+  schemas = false
+}
+
+document "core/article" {
+  meta_doc           = "core/article+meta"
+  statuses           = ["draft", "done", "usable"]
+  bounded_collection = true
+  variants           = ["timeless"]
+
+  workflow = {
+    step_zero           = "draft"
+    checkpoint          = "usable"
+    negative_checkpoint = "unpublished"
+    steps               = ["draft", "done"]
+  }
+
+  label_expression {
+    expression = ".meta(type='core/section').data{code}"
+    template   = "section-{{.code}}"
+  }
+
+  evict_noncurrent_after = "30d"
+}
+
+document "core/article#timeless" {
+  statuses = ["usable"]
+}
+
+metric "wordcount" {
+  aggregation = "increment"
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	plan := fakePlan(t, conf, nil)
+
+	changes := describeChanges(plan.Changes)
+
+	if !slices.Equal(changes, []string{
+		`~ update metric kind "wordcount" (aggregation "replace" => "increment")`,
+	}) {
+		t.Errorf("unexpected changes: %q", changes)
+	}
+
+	drift := describeChanges(plan.Drift)
+
+	if !slices.Equal(drift, []string{
+		"~ register active generation with 0 schemas\n" +
+			"  - core-planning@v1.0.6\n" +
+			"  - core@v1.0.6\n" +
+			"  - tt@v1.0.5",
+		`- remove metric kind "charcount"`,
+	}) {
+		t.Errorf("unexpected drift: %q", drift)
+	}
+
+	writeHCL(t, dir, "author.hcl", `
+document "core/author" {
+  statuses = ["usable"]
+}
+`)
+
+	diags := configDiagnostics(t, dir)
+
+	if len(diags) != 1 ||
+		diags[0].Summary != "Document type outside of managed scope" {
+		t.Errorf("expected an out of scope error, got: %v", diags)
+	}
+}
+
+// customChange is a change that wasn't created by eleconf, and doesn't
+// report a target.
+type customChange struct{}
+
+func (customChange) Describe() (eleconf.ChangeOp, string) {
+	return eleconf.OpUpdate, "custom change"
+}
+
+func (customChange) Execute(_ context.Context, _ eleconf.Clients) error {
+	return nil
+}
+
+func TestNewPlan_UntargetedChange(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "config.hcl", `
+manage {
+  types = ["core/*"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	target := eleconf.ChangeTargetOf(customChange{})
+	if target != (eleconf.ChangeTarget{}) {
+		t.Errorf("expected an empty target, got: %#v", target)
+	}
+
+	plan := eleconf.NewPlan(conf, []eleconf.ConfigurationChange{
+		customChange{},
+	})
+
+	if len(plan.Changes) != 1 || len(plan.Drift) != 0 {
+		t.Errorf("expected the change to be in scope, got %d changes and %d drift",
+			len(plan.Changes), len(plan.Drift))
+	}
+	if conf.Manage.Manages(eleconf.ChangeTarget{Kind: "queue", Name: "print"}) {
+		t.Error("expected a target of an unknown kind to be out of scope")
+	}
+}
+
+func TestCombineConfigs(t *testing.T) {
+	coreDir := t.TempDir()
+	printDir := t.TempDir()
+
+	writeHCL(t, coreDir, "config.hcl", `
+manage {
+  types   = ["core/*"]
+  metrics = ["wordcount"]
+}
+
+document "core/article" {
+  statuses = ["usable"]
+}
+
+metric "wordcount" {}
+`)
+
+	writeHCL(t, printDir, "config.hcl", `
+manage {
+  types   = ["tt/print*"]
+  metrics = []
+  schemas = false
+}
+
+document "tt/print-article" {
+  statuses = ["usable"]
+}
+`)
+
+	readRoots := func() []*eleconf.Config {
+		var roots []*eleconf.Config
+
+		for _, dir := range []string{coreDir, printDir} {
+			conf, err := eleconf.ReadConfigFromDirectory(dir)
+			if err != nil {
+				t.Fatalf("read configuration: %v", err)
+			}
+
+			roots = append(roots, conf)
+		}
+
+		return roots
+	}
+
+	combined, diags := eleconf.CombineConfigs(readRoots())
+	if diags.HasErrors() {
+		t.Fatalf("combine configurations: %v", diags)
+	}
+
+	var types []string
+
+	for _, doc := range combined.Documents {
+		types = append(types, doc.Type)
+	}
+
+	if !slices.Equal(types, []string{"core/article", "tt/print-article"}) {
+		t.Errorf("unexpected combined document types: %v", types)
+	}
+
+	if !combined.Manage.ManagesType("tt/print-page") ||
+		combined.Manage.ManagesType("tt/planning") ||
+		!combined.Manage.ManagesMetric("wordcount") ||
+		combined.Manage.ManagesMetric("charcount") ||
+		!combined.Manage.ManagesSchemas() {
+		t.Errorf("unexpected combined scope: %+v", combined.Manage)
+	}
+
+	writeHCL(t, printDir, "config.hcl", `
+manage {
+  types = ["tt/print*", "core/article"]
+}
+
+document "core/article" {
+  statuses = ["usable"]
+}
+`)
+
+	_, diags = eleconf.CombineConfigs(readRoots())
+
+	var summaries []string
+
+	for _, d := range diags {
+		summaries = append(summaries, d.Summary)
+	}
+
+	if !slices.Equal(summaries, []string{
+		"Document type claimed by multiple roots",
+		"Metric kind claimed by multiple roots",
+		"Overlapping document type scopes",
+		"Overlapping metric kind scopes",
+		"Document type claimed by multiple roots",
+		"Schemas claimed by multiple roots",
+	}) {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+
+	// Scopes that overlap are rejected even if no root declares a type
+	// that the other manages.
+	writeHCL(t, printDir, "config.hcl", `
+manage {
+  types   = ["tt/print*", "core/image*"]
+  metrics = []
+  schemas = false
+}
+
+document "tt/print-article" {
+  statuses = ["usable"]
+}
+`)
+
+	_, diags = eleconf.CombineConfigs(readRoots())
+
+	if len(diags) != 1 || diags[0].Summary != "Overlapping document type scopes" {
+		t.Errorf("expected overlapping scopes to be rejected, got: %v", diags)
+	}
+}
@@ -109,7 +109,8 @@ func GetMetaTypeChanges(
 		}
 
 		changes = append(changes, metaTypeChange{
-			Change: metaOpUnregister,
+			Change:   metaOpUnregister,
+			MetaType: metaType,
 		})
 
 		unregisterRequested[metaType] = true
@@ -130,9 +131,9 @@ const (
 var _ ConfigurationChange = metaTypeChange{}
 
 type metaTypeChange struct {
-	Change   metaOp
-	MainType string
-	MetaType string
+	Change   metaOp `json:"change"`
+	MainType string `json:"main_type,omitempty"`
+	MetaType string `json:"meta_type,omitempty"`
 }
 
 // Execute implements ConfigurationChange.
@@ -167,6 +168,17 @@ func (mc metaTypeChange) Execute(
 	}
 }
 
+// Target implements TargetedChange. Meta type registrations target
+// the meta type, use changes target the main type.
+func (mc metaTypeChange) Target() ChangeTarget {
+	switch mc.Change {
+	case metaOpRegister, metaOpUnregister:
+		return ChangeTarget{Kind: TargetDocumentType, Name: mc.MetaType}
+	default:
+		return ChangeTarget{Kind: TargetDocumentType, Name: mc.MainType}
+	}
+}
+
 func (mc metaTypeChange) Describe() (ChangeOp, string) {
 	switch mc.Change {
 	case metaOpRegister:
@@ -58,8 +58,9 @@ func GetMetricsChanges(
 		agg, wanted := wantMap[k]
 		if !wanted {
 			changes = append(changes, &MetricUpdate{
-				Operation: OpRemove,
-				Kind:      k,
+				Operation:      OpRemove,
+				Kind:           k,
+				OldAggregation: currAgg,
 			})
 
 			continue
@@ -96,10 +97,15 @@ func GetMetricsChanges(
 var _ ConfigurationChange = &MetricUpdate{}
 
 type MetricUpdate struct {
-	Operation      ChangeOp
-	Kind           string
-	OldAggregation MetricAggregation
-	Aggregation    MetricAggregation
+	Operation      ChangeOp          `json:"operation"`
+	Kind           string            `json:"kind"`
+	OldAggregation MetricAggregation `json:"old_aggregation,omitempty"`
+	Aggregation    MetricAggregation `json:"aggregation,omitempty"`
+}
+
+// Target implements TargetedChange.
+func (m *MetricUpdate) Target() ChangeTarget {
+	return ChangeTarget{Kind: TargetMetric, Name: m.Kind}
 }
 
 // Describe implements ConfigurationChange.
@@ -0,0 +1,177 @@
+package eleconf
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// domainRank is the order that changes are listed in when they don't
+// depend on each other. Dependencies are handled by mustPrecede, the rank
+// only makes the order predictable.
+var domainRank = map[ChangeDomain]int{
+	DomainSchema:     0,
+	DomainMetaType:   1,
+	DomainStatus:     2,
+	DomainWorkflow:   3,
+	DomainMetric:     4,
+	DomainTypeConfig: 5,
+}
+
+var opRank = map[ChangeOp]int{
+	OpAdd:    0,
+	OpUpdate: 1,
+	OpRemove: 2,
+}
+
+// OrderChanges sorts changes so that every change comes after the changes it
+// depends on:
+//
+//   - schema generation registration before type configuration changes, and
+//     before status, workflow and meta type changes for the document types
+//     that the generation declares
+//   - status additions before workflows are set for the type
+//   - workflow changes before statuses are disabled for the type
+//   - meta type registration before the meta type is used
+//   - removed meta type uses before the meta type is unregistered, and
+//     before a new meta type is used for the main type
+//
+// Changes that don't depend on each other are ordered by domain, target,
+// operation and description, so the order is the same between runs.
+func OrderChanges(
+	changes []ConfigurationChange,
+) ([]ConfigurationChange, error) {
+	list := make([]orderedChange, len(changes))
+
+	for i, c := range changes {
+		op, desc := c.Describe()
+
+		list[i] = orderedChange{
+			change: c,
+			domain: changeDomain(c),
+			target: ChangeTargetOf(c).Name,
+			op:     opRank[op],
+			desc:   desc,
+		}
+
+		gc, ok := c.(generationChange)
+		if !ok {
+			continue
+		}
+
+		declared, err := DeclaredDocumentTypes(gc.Schemas)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"get document types declared by the generation: %w", err)
+		}
+
+		list[i].declares = declared
+	}
+
+	slices.SortStableFunc(list, func(a, b orderedChange) int {
+		return cmp.Or(
+			cmp.Compare(domainRank[a.domain], domainRank[b.domain]),
+			cmp.Compare(a.target, b.target),
+			cmp.Compare(a.op, b.op),
+			cmp.Compare(a.desc, b.desc),
+		)
+	})
+
+	// Count the unmet dependencies of every change, and pick the first
+	// change in sort order that has none until all changes are ordered.
+	// Plans are small enough that the quadratic cost doesn't matter.
+	pending := make([]int, len(list))
+
+	for i := range list {
+		for j := range list {
+			if i != j && mustPrecede(list[j], list[i]) {
+				pending[i]++
+			}
+		}
+	}
+
+	ordered := make([]ConfigurationChange, 0, len(list))
+	done := make([]bool, len(list))
+
+	for len(ordered) < len(list) {
+		next := -1
+
+		for i := range list {
+			if !done[i] && pending[i] == 0 {
+				next = i
+
+				break
+			}
+		}
+
+		if next == -1 {
+			return nil, errors.New(
+				"circular dependency between configuration changes")
+		}
+
+		done[next] = true
+		ordered = append(ordered, list[next].change)
+
+		for i := range list {
+			if !done[i] && mustPrecede(list[next], list[i]) {
+				pending[i]--
+			}
+		}
+	}
+
+	return ordered, nil
+}
+
+// orderedChange is a change with the values it's ordered by.
+type orderedChange struct {
+	change ConfigurationChange
+	domain ChangeDomain
+	target string
+	op     int
+	desc   string
+	// declares are the document types declared by a generation change.
+	declares map[string]bool
+}
+
+// mustPrecede checks if the change a has to be executed before b.
+func mustPrecede(a, b orderedChange) bool {
+	if _, ok := a.change.(generationChange); ok {
+		switch b.domain {
+		case DomainTypeConfig:
+			return true
+		case DomainStatus, DomainWorkflow, DomainMetaType:
+			return a.declares[b.target]
+		default:
+			return false
+		}
+	}
+
+	switch b := b.change.(type) {
+	case *DocWorkflowUpdate:
+		s, ok := a.change.(statusChange)
+
+		return ok && !s.Disable && s.Type == b.Type &&
+			b.Operation != OpRemove
+	case statusChange:
+		w, ok := a.change.(*DocWorkflowUpdate)
+
+		return ok && b.Disable && w.Type == b.Type
+	case metaTypeChange:
+		m, ok := a.change.(metaTypeChange)
+		if !ok {
+			return false
+		}
+
+		switch {
+		case m.Change == metaOpRegister && b.Change == metaOpRegisterUse:
+			return m.MetaType == b.MetaType
+		case m.Change == metaOpUnregisterUse && b.Change == metaOpUnregister:
+			return m.MetaType == b.MetaType
+		case m.Change == metaOpUnregisterUse && b.Change == metaOpRegisterUse:
+			return m.MainType == b.MainType
+		}
+	}
+
+	return false
+}
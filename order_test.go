@@ -0,0 +1,160 @@
+package eleconf_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+func TestGetChanges_DependencyOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "config.hcl", `
+manage {
+  types   = ["core/*"]
+  metrics = []
+  schemas = false
+}
+
+document "core/article" {
+  meta_doc           = "core/article+meta"
+  statuses           = ["draft", "approved", "usable"]
+  bounded_collection = true
+  variants           = ["timeless"]
+
+  workflow = {
+    step_zero           = "draft"
+    checkpoint          = "usable"
+    negative_checkpoint = "unpublished"
+    steps               = ["draft", "approved"]
+  }
+
+  label_expression {
+    expression = ".meta(type='core/section').data{code}"
+    template   = "section-{{.code}}"
+  }
+
+  evict_noncurrent_after = "30d"
+}
+
+document "core/article#timeless" {
+  statuses = ["usable"]
+}
+
+document "core/author" {
+  meta_doc = "core/author+meta"
+  statuses = ["usable"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	describe := func() []string {
+		return describeChanges(fakePlan(t, conf, nil).Changes)
+	}
+
+	changes := describe()
+
+	position := func(prefix string) int {
+		idx := slices.IndexFunc(changes, func(s string) bool {
+			return strings.HasPrefix(s, prefix)
+		})
+		if idx == -1 {
+			t.Fatalf("missing change %q in %q", prefix, changes)
+		}
+
+		return idx
+	}
+
+	addStatus := position(`+ status "approved" for "core/article"`)
+	workflow := position(`~ update workflow for "core/article"`)
+	disableStatus := position(`- status "done" for "core/article"`)
+	registerMeta := position(`+ meta type "core/author+meta"`)
+	useMeta := position(`+ meta type "core/author+meta" for "core/author"`)
+
+	if addStatus > workflow || workflow > disableStatus {
+		t.Errorf("expected the status to be added before the workflow "+
+			"update, and disabled after it, got: %q", changes)
+	}
+
+	if registerMeta > useMeta {
+		t.Errorf("expected meta type registration before use, got: %q",
+			changes)
+	}
+
+	for range 5 {
+		again := describe()
+		if !slices.Equal(changes, again) {
+			t.Fatalf("expected a deterministic order, got %q and %q",
+				changes, again)
+		}
+	}
+}
+
+func TestGetChanges_WorkflowRemoval(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "config.hcl", `
+manage {
+  types   = ["core/article", "core/event"]
+  metrics = []
+}
+
+document "core/article" {
+  statuses = ["usable"]
+}
+
+document "core/event" {
+  statuses = ["usable"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	schemas := []eleconf.LoadedSchema{{
+		Lock: eleconf.SchemaLock{Name: "core", Version: "v1.0.7"},
+		Data: []byte(`{"documents": [
+  {"declares": "core/article"},
+  {"declares": "core/event"}
+]}`),
+	}}
+
+	changes := describeChanges(fakePlan(t, conf, schemas).Changes)
+
+	position := func(prefix string) int {
+		idx := slices.IndexFunc(changes, func(s string) bool {
+			return strings.HasPrefix(s, prefix)
+		})
+		if idx == -1 {
+			t.Fatalf("missing change %q in %q", prefix, changes)
+		}
+
+		return idx
+	}
+
+	generation := position(`~ register active generation`)
+	addStatus := position(`+ status "usable" for "core/event"`)
+	removeWorkflow := position(`- remove workflow for "core/article"`)
+
+	if generation > addStatus {
+		t.Errorf("expected the generation to be registered before "+
+			"statuses are added for new types, got: %q", changes)
+	}
+
+	for _, status := range []string{"draft", "done"} {
+		disable := position(`- status "` + status + `" for "core/article"`)
+
+		if removeWorkflow > disable {
+			t.Errorf("expected the workflow to be removed before "+
+				"status %q is disabled, got: %q", status, changes)
+		}
+	}
+}
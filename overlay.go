@@ -0,0 +1,141 @@
+package eleconf
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// overlayRemoval is a "remove" block in an environment overlay that removes
+// blocks from the base configuration.
+type overlayRemoval struct {
+	// Documents are the document types to remove.
+	Documents []string `hcl:"documents,optional"`
+	// SchemaSets are the schema sets to remove.
+	SchemaSets []string `hcl:"schema_sets,optional"`
+	// Metrics are the metric kinds to remove.
+	Metrics []string `hcl:"metrics,optional"`
+
+	DeclRange hcl.Range
+}
+
+var removalSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "remove"},
+	},
+}
+
+func decodeRemovals(
+	body hcl.Body,
+) ([]overlayRemoval, hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := body.PartialContent(removalSchema)
+
+	var removals []overlayRemoval
+
+	for _, block := range content.Blocks {
+		r := overlayRemoval{
+			DeclRange: block.DefRange,
+		}
+
+		diags = append(diags, gohcl.DecodeBody(block.Body, nil, &r)...)
+
+		removals = append(removals, r)
+	}
+
+	return removals, remain, diags
+}
+
+// applyOverlay merges an environment overlay into the base configuration.
+// Blocks in the overlay replace base blocks with the same name, other blocks
+// are added, and a manage block replaces the base scope. Removals are applied
+// last.
+func applyOverlay(
+	base *Config, overlay *Config, removals []overlayRemoval,
+) hcl.Diagnostics {
+	base.Documents = overlayBlocks(base.Documents, overlay.Documents,
+		func(d DocumentConfig) string { return d.Type })
+	base.SchemaSets = overlayBlocks(base.SchemaSets, overlay.SchemaSets,
+		func(s SchemaSet) string { return s.Name })
+	base.Metric = overlayBlocks(base.Metric, overlay.Metric,
+		func(m MetricKind) string { return m.Kind })
+	base.WorkflowProfiles = overlayBlocks(
+		base.WorkflowProfiles, overlay.WorkflowProfiles,
+		func(p WorkflowProfile) string { return p.Name })
+
+	if overlay.Manage != nil {
+		base.Manage = overlay.Manage
+	}
+
+	var diags hcl.Diagnostics
+
+	for _, r := range removals {
+		var rDiags hcl.Diagnostics
+
+		base.Documents, rDiags = removeBlocks(
+			"document type", base.Documents, r.Documents, r.DeclRange,
+			func(d DocumentConfig) string { return d.Type })
+		diags = append(diags, rDiags...)
+
+		base.SchemaSets, rDiags = removeBlocks(
+			"schema set", base.SchemaSets, r.SchemaSets, r.DeclRange,
+			func(s SchemaSet) string { return s.Name })
+		diags = append(diags, rDiags...)
+
+		base.Metric, rDiags = removeBlocks(
+			"metric kind", base.Metric, r.Metrics, r.DeclRange,
+			func(m MetricKind) string { return m.Kind })
+		diags = append(diags, rDiags...)
+	}
+
+	return diags
+}
+
+func overlayBlocks[T any](base []T, overlay []T, name func(T) string) []T {
+	for _, o := range overlay {
+		idx := slices.IndexFunc(base, func(b T) bool {
+			return name(b) == name(o)
+		})
+
+		if idx == -1 {
+			base = append(base, o)
+
+			continue
+		}
+
+		base[idx] = o
+	}
+
+	return base
+}
+
+func removeBlocks[T any](
+	what string, blocks []T, remove []string, rng hcl.Range,
+	name func(T) string,
+) ([]T, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	for _, n := range remove {
+		idx := slices.IndexFunc(blocks, func(b T) bool {
+			return name(b) == n
+		})
+
+		if idx == -1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Removing undefined " + what,
+				Detail: fmt.Sprintf(
+					"The %s %q can't be removed, as it hasn't been defined.",
+					what, n),
+				Subject: rng.Ptr(),
+			})
+
+			continue
+		}
+
+		blocks = slices.Delete(blocks, idx, idx+1)
+	}
+
+	return blocks, diags
+}
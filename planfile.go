@@ -0,0 +1,374 @@
+package eleconf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ttab/elephant-api/repository"
+	"github.com/ttab/newsdoc"
+)
+
+const planFileVersion = 2
+
+// ErrRemoteChanged is returned when a saved plan is applied to a repository
+// that has changed since the plan was made.
+var ErrRemoteChanged = errors.New(
+	"the repository has changed since the plan was made, create a new plan")
+
+// PlanFile is a plan saved to disk, so that the plan that was reviewed is
+// the plan that gets applied. Changes carry everything they need to be
+// executed, including the schema and exemplar data. The fingerprint of the
+// repository state within the managed scope is used to refuse applying the
+// plan if the repository has changed, Types are the configured document types
+// that are fingerprinted even if they don't exist in the repository yet.
+// Environment is set by the caller, so that a plan isn't applied to the wrong
+// environment.
+type PlanFile struct {
+	Version     int            `json:"version"`
+	Created     time.Time      `json:"created"`
+	Environment string         `json:"environment,omitempty"`
+	Scope       *ManageScope   `json:"scope,omitempty"`
+	Fingerprint string         `json:"remote_fingerprint"`
+	Types       []string       `json:"types,omitempty"`
+	Schemas     []SchemaLock   `json:"schemas,omitempty"`
+	Exemplars   []ExemplarLock `json:"exemplars,omitempty"`
+	Changes     []SavedChange  `json:"changes"`
+	Drift       []SavedChange  `json:"drift,omitempty"`
+}
+
+// SavedChange is a configuration change in a plan file. The description is
+// for human readers, the change is decoded from the data.
+type SavedChange struct {
+	Domain      ChangeDomain    `json:"domain"`
+	Operation   ChangeOp        `json:"operation"`
+	Description string          `json:"description"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// NewPlanFile creates a plan file for a plan made from the configuration,
+// schemas and exemplars. The repository is read to fingerprint its current
+// state.
+func NewPlanFile(
+	ctx context.Context,
+	clients Clients,
+	conf *Config,
+	plan *Plan,
+	schemas []LoadedSchema,
+	exemplars []LoadedExemplar,
+) (*PlanFile, error) {
+	var types []string
+
+	for _, doc := range conf.Documents {
+		types = append(types, doc.Type)
+
+		if doc.MetaDocType != "" {
+			types = append(types, doc.MetaDocType)
+		}
+	}
+
+	fingerprint, err := RemoteFingerprint(ctx, clients, conf.Manage, types)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint repository state: %w", err)
+	}
+
+	pf := PlanFile{
+		Version:     planFileVersion,
+		Created:     time.Now(),
+		Scope:       conf.Manage,
+		Fingerprint: fingerprint,
+		Types:       types,
+		Exemplars:   ExemplarLocks(exemplars),
+		Changes:     []SavedChange{},
+	}
+
+	for _, s := range schemas {
+		pf.Schemas = append(pf.Schemas, s.Lock)
+	}
+
+	for _, c := range plan.Changes {
+		sc, err := saveChange(c)
+		if err != nil {
+			return nil, err
+		}
+
+		pf.Changes = append(pf.Changes, sc)
+	}
+
+	for _, c := range plan.Drift {
+		sc, err := saveChange(c)
+		if err != nil {
+			return nil, err
+		}
+
+		pf.Drift = append(pf.Drift, sc)
+	}
+
+	return &pf, nil
+}
+
+// LoadPlanFile reads a plan file from disk.
+func LoadPlanFile(fileName string) (*PlanFile, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("read plan file: %w", err)
+	}
+
+	var pf PlanFile
+
+	err = json.Unmarshal(data, &pf)
+	if err != nil {
+		return nil, fmt.Errorf("parse plan file: %w", err)
+	}
+
+	if pf.Version != planFileVersion {
+		return nil, fmt.Errorf("unsupported plan file version %d",
+			pf.Version)
+	}
+
+	return &pf, nil
+}
+
+// Save writes the plan file to disk.
+func (pf *PlanFile) Save(fileName string) error {
+	data, err := json.MarshalIndent(pf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal plan data: %w", err)
+	}
+
+	err = os.WriteFile(fileName, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("write to file: %w", err)
+	}
+
+	return nil
+}
+
+// CheckRemote checks that the repository state is the same as when the plan
+// was made. Returns ErrRemoteChanged if it isn't.
+func (pf *PlanFile) CheckRemote(ctx context.Context, clients Clients) error {
+	fingerprint, err := RemoteFingerprint(
+		ctx, clients, pf.Scope, pf.Types)
+	if err != nil {
+		return fmt.Errorf("fingerprint repository state: %w", err)
+	}
+
+	if fingerprint != pf.Fingerprint {
+		return ErrRemoteChanged
+	}
+
+	return nil
+}
+
+// Plan decodes the saved changes.
+func (pf *PlanFile) Plan() (*Plan, error) {
+	var p Plan
+
+	for i, sc := range pf.Changes {
+		c, err := loadChange(sc)
+		if err != nil {
+			return nil, fmt.Errorf("change %d: %w", i+1, err)
+		}
+
+		p.Changes = append(p.Changes, c)
+	}
+
+	for i, sc := range pf.Drift {
+		c, err := loadChange(sc)
+		if err != nil {
+			return nil, fmt.Errorf("drift %d: %w", i+1, err)
+		}
+
+		p.Drift = append(p.Drift, c)
+	}
+
+	return &p, nil
+}
+
+// savedGeneration is the saved form of a generationChange. Only the names
+// and versions of the current schemas and exemplars are kept, they are used
+// for the description.
+type savedGeneration struct {
+	Schemas          []LoadedSchema              `json:"schemas"`
+	Exemplars        []savedExemplar             `json:"exemplars,omitempty"`
+	Activation       repository.SchemaActivation `json:"activation"`
+	Current          []SchemaLock                `json:"current,omitempty"`
+	CurrentExemplars []ExemplarLock              `json:"current_exemplars,omitempty"`
+}
+
+type savedExemplar struct {
+	Lock     ExemplarLock     `json:"lock"`
+	Document newsdoc.Document `json:"document"`
+}
+
+func saveChange(c ConfigurationChange) (SavedChange, error) {
+	domain := changeDomain(c)
+	if domain == "" {
+		return SavedChange{}, fmt.Errorf(
+			"unsupported change type %T", c)
+	}
+
+	var data any = c
+
+	gc, ok := c.(generationChange)
+	if ok {
+		data = saveGeneration(gc)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return SavedChange{}, fmt.Errorf(
+			"marshal %s change: %w", domain, err)
+	}
+
+	op, desc := c.Describe()
+
+	return SavedChange{
+		Domain:      domain,
+		Operation:   op,
+		Description: desc,
+		Data:        raw,
+	}, nil
+}
+
+func saveGeneration(gc generationChange) savedGeneration {
+	sg := savedGeneration{
+		Schemas:    gc.Schemas,
+		Activation: gc.Activation,
+	}
+
+	for _, ex := range gc.Exemplars {
+		sg.Exemplars = append(sg.Exemplars, savedExemplar{
+			Lock:     ex.Lock,
+			Document: ex.Document,
+		})
+	}
+
+	for _, s := range gc.Current {
+		sg.Current = append(sg.Current, SchemaLock{
+			Name:    s.Name,
+			Version: s.Version,
+		})
+	}
+
+	for _, ex := range gc.CurrentExemplars {
+		sg.CurrentExemplars = append(sg.CurrentExemplars,
+			ExemplarLock{
+				Name: ex.Name,
+				Hash: ex.VersionHash,
+			})
+	}
+
+	return sg
+}
+
+func loadChange(sc SavedChange) (ConfigurationChange, error) {
+	switch sc.Domain {
+	case DomainSchema:
+		var sg savedGeneration
+
+		err := json.Unmarshal(sc.Data, &sg)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal schema change: %w", err)
+		}
+
+		return loadGeneration(sg)
+	case DomainStatus:
+		return unmarshalChange[statusChange](sc)
+	case DomainWorkflow:
+		c, err := unmarshalChange[*DocWorkflowUpdate](sc)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Operation == OpUpdate {
+			c.diff = cmp.Diff(c.Current, c.Wanted)
+		}
+
+		return c, nil
+	case DomainMetaType:
+		return unmarshalChange[metaTypeChange](sc)
+	case DomainMetric:
+		return unmarshalChange[*MetricUpdate](sc)
+	case DomainTypeConfig:
+		c, err := unmarshalChange[*TypeConfigurationChange](sc)
+		if err != nil {
+			return nil, err
+		}
+
+		c.diff = cmp.Diff(c.Current, c.Wanted)
+
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unknown change domain %q", sc.Domain)
+	}
+}
+
+func unmarshalChange[T ConfigurationChange](sc SavedChange) (T, error) {
+	var c T
+
+	err := json.Unmarshal(sc.Data, &c)
+	if err != nil {
+		return c, fmt.Errorf("unmarshal %s change: %w", sc.Domain, err)
+	}
+
+	return c, nil
+}
+
+// loadGeneration restores a generation change, and verifies the schema and
+// exemplar data against their hashes.
+func loadGeneration(sg savedGeneration) (ConfigurationChange, error) {
+	gc := generationChange{
+		Schemas:    sg.Schemas,
+		Activation: sg.Activation,
+	}
+
+	for _, s := range sg.Schemas {
+		hash := fmt.Sprintf("%x", sha256.Sum256(s.Data))
+		if hash != s.Lock.Hash {
+			return nil, fmt.Errorf("hash mismatch for schema %q",
+				s.Lock.Name)
+		}
+	}
+
+	for _, ex := range sg.Exemplars {
+		canonical, err := json.Marshal(ex.Document)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"canonicalize exemplar %q: %w", ex.Lock.Name, err)
+		}
+
+		if exemplarHash(canonical) != ex.Lock.Hash {
+			return nil, fmt.Errorf("hash mismatch for exemplar %q",
+				ex.Lock.Name)
+		}
+
+		gc.Exemplars = append(gc.Exemplars, LoadedExemplar{
+			Lock:      ex.Lock,
+			Document:  ex.Document,
+			Canonical: canonical,
+		})
+	}
+
+	for _, s := range sg.Current {
+		gc.Current = append(gc.Current, &repository.Schema{
+			Name:    s.Name,
+			Version: s.Version,
+		})
+	}
+
+	for _, ex := range sg.CurrentExemplars {
+		gc.CurrentExemplars = append(gc.CurrentExemplars,
+			&repository.Exemplar{
+				Name:        ex.Name,
+				VersionHash: ex.Hash,
+			})
+	}
+
+	return gc, nil
+}
@@ -0,0 +1,111 @@
+package eleconf_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/eleconf"
+	"github.com/ttab/elephant-api/repository"
+)
+
+func TestPlanFile_RoundTrip(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "config.hcl", `
+manage {
+  types   = ["core/*"]
+  schemas = false
+}
+
+document "core/article" {
+  meta_doc = "core/article+meta"
+  statuses = ["draft", "done", "usable", "cancelled"]
+}
+
+document "core/factbox" {
+  statuses = ["usable"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	repo := newFakeRepository()
+	clients := fakeClients(repo)
+
+	plan, err := eleconf.PlanChanges(ctx, clients, conf, nil, nil,
+		repository.SchemaActivation_ACTIVATION_ACTIVE)
+	if err != nil {
+		t.Fatalf("plan changes: %v", err)
+	}
+
+	pf, err := eleconf.NewPlanFile(ctx, clients, conf, plan, nil, nil)
+	if err != nil {
+		t.Fatalf("create plan file: %v", err)
+	}
+
+	planPath := filepath.Join(dir, "plan.json")
+
+	err = pf.Save(planPath)
+	if err != nil {
+		t.Fatalf("save plan file: %v", err)
+	}
+
+	data, err := os.ReadFile(planPath)
+	if err != nil {
+		t.Fatalf("read plan file: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"evict_noncurrent_after": 30`) {
+		t.Errorf("expected the eviction period in days in the plan file")
+	}
+
+	loaded, err := eleconf.LoadPlanFile(planPath)
+	if err != nil {
+		t.Fatalf("load plan file: %v", err)
+	}
+
+	loadedPlan, err := loaded.Plan()
+	if err != nil {
+		t.Fatalf("decode plan: %v", err)
+	}
+
+	want := describeChanges(plan.Changes)
+	got := describeChanges(loadedPlan.Changes)
+
+	if len(want) == 0 || !slices.Equal(want, got) {
+		t.Errorf("expected the loaded plan to have the changes %q, got %q",
+			want, got)
+	}
+
+	err = loaded.CheckRemote(ctx, clients)
+	if err != nil {
+		t.Fatalf("expected an unchanged repository, got: %v", err)
+	}
+
+	repo.statuses["core/factbox"] = []string{"usable"}
+
+	err = loaded.CheckRemote(ctx, clients)
+	if !errors.Is(err, eleconf.ErrRemoteChanged) {
+		t.Errorf("expected the statuses of a new type to be detected, got: %v",
+			err)
+	}
+
+	delete(repo.statuses, "core/factbox")
+
+	repo.statuses["core/article"] = append(
+		repo.statuses["core/article"], "cancelled")
+
+	err = loaded.CheckRemote(ctx, clients)
+	if !errors.Is(err, eleconf.ErrRemoteChanged) {
+		t.Errorf("expected the repository change to be detected, got: %v",
+			err)
+	}
+}
@@ -0,0 +1,125 @@
+package eleconf
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// WorkflowProfile is a named set of statuses and a workflow that documents
+// can use instead of declaring their own.
+type WorkflowProfile struct {
+	Name      string    `hcl:"name,label" json:"name"`
+	DeclRange hcl.Range `hcl:",def_range" json:"-"`
+	// Statuses are the statuses of documents using the profile.
+	Statuses []string `hcl:"statuses,optional" json:"statuses,omitempty"`
+	// Workflow is the workflow of documents using the profile.
+	Workflow *DocumentWorkflow `hcl:"workflow,optional" json:"workflow,omitempty"`
+}
+
+// resolveProfiles applies workflow profiles and status additions/removals to
+// the documents.
+func resolveProfiles(conf *Config) hcl.Diagnostics {
+	profiles, diags := indexProfiles(conf.WorkflowProfiles)
+
+	for i := range conf.Documents {
+		diags = append(diags, resolveDocumentProfile(
+			&conf.Documents[i], profiles)...)
+	}
+
+	return diags
+}
+
+func indexProfiles(
+	list []WorkflowProfile,
+) (map[string]*WorkflowProfile, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	profiles := make(map[string]*WorkflowProfile, len(list))
+
+	for i := range list {
+		p := &list[i]
+
+		first, dup := profiles[p.Name]
+		if dup {
+			diags = append(diags, duplicateDiags(
+				"workflow profile", p.Name,
+				first.DeclRange, p.DeclRange)...)
+
+			continue
+		}
+
+		profiles[p.Name] = p
+	}
+
+	return profiles, diags
+}
+
+// resolveDocumentProfile applies the workflow profile and status
+// additions/removals to a document.
+func resolveDocumentProfile(
+	doc *DocumentConfig, profiles map[string]*WorkflowProfile,
+) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if doc.Profile != "" {
+		p, ok := profiles[doc.Profile]
+		if !ok {
+			return hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown workflow profile",
+				Detail: fmt.Sprintf(
+					"The document %q uses the workflow profile %q, which hasn't been declared.",
+					doc.Type, doc.Profile),
+				Subject: doc.DeclRange.Ptr(),
+			}}
+		}
+
+		// Statuses and workflow declared on the document take
+		// precedence over the profile.
+		if doc.Statuses == nil {
+			doc.Statuses = slices.Clone(p.Statuses)
+		}
+
+		if doc.Workflow == nil && p.Workflow != nil {
+			wf := *p.Workflow
+			wf.Steps = slices.Clone(wf.Steps)
+
+			doc.Workflow = &wf
+		}
+	}
+
+	for _, s := range doc.AddStatuses {
+		if !slices.Contains(doc.Statuses, s) {
+			doc.Statuses = append(doc.Statuses, s)
+		}
+	}
+
+	for _, s := range doc.DropStatuses {
+		if !slices.Contains(doc.Statuses, s) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Dropped status not present",
+				Detail: fmt.Sprintf(
+					"The document %q drops the status %q, but it isn't one of its statuses.",
+					doc.Type, s),
+				Subject: doc.DeclRange.Ptr(),
+			})
+		}
+
+		doc.Statuses = slices.DeleteFunc(doc.Statuses,
+			func(v string) bool { return v == s })
+
+		if doc.Workflow != nil {
+			doc.Workflow.Steps = slices.DeleteFunc(
+				doc.Workflow.Steps,
+				func(v string) bool { return v == s })
+		}
+	}
+
+	doc.AddStatuses = nil
+	doc.DropStatuses = nil
+
+	return diags
+}
@@ -0,0 +1,179 @@
+package eleconf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/ttab/elephant-api/repository"
+	"github.com/ttab/elephantine"
+	"github.com/twitchtv/twirp"
+)
+
+// remoteState is the repository state that changes are computed from.
+type remoteState struct {
+	Types        map[string]remoteType `json:"types"`
+	MetaTypes    map[string][]string   `json:"meta_types"`
+	Metrics      map[string]string     `json:"metrics"`
+	Schemas      map[string]string     `json:"schemas,omitempty"`
+	GenerationID int64                 `json:"generation_id,omitempty"`
+}
+
+type remoteType struct {
+	Statuses      map[string][]string `json:"statuses"`
+	Workflow      *DocumentWorkflow   `json:"workflow,omitempty"`
+	Configuration *TypeConfigSpec     `json:"configuration,omitempty"`
+}
+
+// RemoteFingerprint reads the repository state within the managed scope and
+// returns a hash of it. A plan is only valid as long as the fingerprint of
+// the repository stays the same. Types are the configured document types,
+// so that their statuses and workflows are covered before they exist in the
+// repository.
+func RemoteFingerprint(
+	ctx context.Context, clients Clients, scope *ManageScope, types []string,
+) (string, error) {
+	schemas := clients.GetSchemas()
+	workflows := clients.GetWorkflows()
+
+	state := remoteState{
+		Types:     make(map[string]remoteType),
+		MetaTypes: make(map[string][]string),
+		Metrics:   make(map[string]string),
+	}
+
+	if scope.ManagesSchemas() {
+		active, err := schemas.ListActive(ctx,
+			&repository.ListActiveSchemasRequest{})
+		if err != nil {
+			return "", fmt.Errorf("get active schemas: %w", err)
+		}
+
+		state.Schemas = make(map[string]string, len(active.Schemas))
+		state.GenerationID = active.GenerationId
+
+		for _, s := range active.Schemas {
+			state.Schemas[s.Name] = s.Version
+		}
+	}
+
+	current, err := schemas.GetDocumentTypes(ctx,
+		&repository.GetDocumentTypesRequest{})
+	if err != nil {
+		return "", fmt.Errorf("get current document types: %w", err)
+	}
+
+	allTypes := slices.Concat(current.Types, types)
+
+	slices.Sort(allTypes)
+
+	for _, typ := range slices.Compact(allTypes) {
+		if !scope.ManagesType(typ) {
+			continue
+		}
+
+		rt := remoteType{
+			Statuses: make(map[string][]string),
+		}
+
+		wf, err := workflows.GetWorkflow(ctx,
+			&repository.GetWorkflowRequest{Type: typ})
+
+		switch {
+		case elephantine.IsTwirpErrorCode(err, twirp.NotFound):
+		case err != nil:
+			return "", fmt.Errorf(
+				"get current workflow for %q: %w", typ, err)
+		default:
+			rt.Workflow = rpcToWorkflow(wf.Workflow)
+		}
+
+		tc, err := schemas.GetTypeConfiguration(ctx,
+			&repository.GetTypeConfigurationRequest{Type: typ})
+
+		switch {
+		case elephantine.IsTwirpErrorCode(err, twirp.NotFound):
+		case err != nil:
+			return "", fmt.Errorf(
+				"get current type configuration for %q: %w", typ, err)
+		case tc.Configuration != nil:
+			spec := rpcToTypeConfigSpec(tc.Configuration)
+
+			rt.Configuration = &spec
+		}
+
+		statusTypes := []string{typ}
+
+		if rt.Configuration != nil {
+			for _, v := range rt.Configuration.Variants {
+				statusTypes = append(statusTypes, typ+"#"+v)
+			}
+		}
+
+		for _, st := range statusTypes {
+			res, err := workflows.GetStatuses(ctx,
+				&repository.GetStatusesRequest{Type: st})
+			if err != nil {
+				return "", fmt.Errorf(
+					"get statuses for %q: %w", st, err)
+			}
+
+			var names []string
+
+			for _, s := range res.Statuses {
+				names = append(names, s.Name)
+			}
+
+			slices.Sort(names)
+
+			rt.Statuses[st] = names
+		}
+
+		state.Types[typ] = rt
+	}
+
+	metaTypes, err := schemas.GetMetaTypes(ctx,
+		&repository.GetMetaTypesRequest{})
+	if err != nil {
+		return "", fmt.Errorf("get current meta types: %w", err)
+	}
+
+	for _, m := range metaTypes.Types {
+		var usedBy []string
+
+		for _, main := range m.UsedBy {
+			if scope.ManagesType(main) {
+				usedBy = append(usedBy, main)
+			}
+		}
+
+		if len(usedBy) == 0 && !scope.ManagesType(m.Name) {
+			continue
+		}
+
+		slices.Sort(usedBy)
+
+		state.MetaTypes[m.Name] = usedBy
+	}
+
+	kinds, err := clients.GetMetrics().GetKinds(ctx,
+		&repository.GetMetricKindsRequest{})
+	if err != nil {
+		return "", fmt.Errorf("get current metric kinds: %w", err)
+	}
+
+	for _, k := range kinds.Kinds {
+		if scope.ManagesMetric(k.Name) {
+			state.Metrics[k.Name] = k.Aggregation.String()
+		}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("marshal remote state: %w", err)
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
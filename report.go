@@ -0,0 +1,170 @@
+package eleconf
+
+import (
+	"strconv"
+
+	"github.com/ttab/elephant-api/repository"
+)
+
+// PlanReport is the machine readable form of a plan.
+type PlanReport struct {
+	Changes []ChangeReport `json:"changes"`
+	Drift   []ChangeReport `json:"drift"`
+}
+
+// ChangeReport describes a change in a machine readable form. Before and
+// After are the values that the change replaces and sets, they are omitted
+// when there is no value, f.ex. Before for additions.
+type ChangeReport struct {
+	Domain      ChangeDomain `json:"domain"`
+	Operation   string       `json:"operation"`
+	Target      ChangeTarget `json:"target"`
+	Description string       `json:"description"`
+	Before      any          `json:"before,omitempty"`
+	After       any          `json:"after,omitempty"`
+	Warnings    []string     `json:"warnings,omitempty"`
+}
+
+// Report returns the machine readable form of the plan.
+func (p *Plan) Report() PlanReport {
+	r := PlanReport{
+		Changes: make([]ChangeReport, 0, len(p.Changes)),
+		Drift:   make([]ChangeReport, 0, len(p.Drift)),
+	}
+
+	for _, c := range p.Changes {
+		r.Changes = append(r.Changes, ReportChange(c))
+	}
+
+	for _, c := range p.Drift {
+		r.Drift = append(r.Drift, ReportChange(c))
+	}
+
+	return r
+}
+
+// generationValues are the schema and exemplar versions of a generation.
+type generationValues struct {
+	Activation string            `json:"activation,omitempty"`
+	Schemas    map[string]string `json:"schemas"`
+	Exemplars  map[string]string `json:"exemplars,omitempty"`
+}
+
+// typeConfigValues is a type configuration with the eviction period in days,
+// like in the configuration files.
+type typeConfigValues struct {
+	BoundedCollection    bool              `json:"bounded_collection"`
+	TimeExpressions      []TimeExpression  `json:"time_expressions,omitempty"`
+	LabelExpressions     []LabelExpression `json:"label_expressions,omitempty"`
+	Variants             []string          `json:"variants,omitempty"`
+	EvictNoncurrentAfter string            `json:"evict_noncurrent_after,omitempty"`
+}
+
+func newTypeConfigValues(s TypeConfigSpec) typeConfigValues {
+	v := typeConfigValues{
+		BoundedCollection: s.Bounded,
+		TimeExpressions:   s.TimeExpressions,
+		LabelExpressions:  s.LabelExpressions,
+		Variants:          s.Variants,
+	}
+
+	if s.EvictNoncurrentAfter > 0 {
+		v.EvictNoncurrentAfter = strconv.FormatInt(
+			int64(s.EvictNoncurrentAfter/day), 10) + "d"
+	}
+
+	return v
+}
+
+// ReportChange describes a change in a machine readable form.
+func ReportChange(c ConfigurationChange) ChangeReport {
+	op, desc := c.Describe()
+
+	r := ChangeReport{
+		Domain:      changeDomain(c),
+		Operation:   op.Name(),
+		Target:      ChangeTargetOf(c),
+		Description: desc,
+	}
+
+	w, ok := c.(interface{ Warnings() []string })
+	if ok {
+		r.Warnings = w.Warnings()
+	}
+
+	switch c := c.(type) {
+	case generationChange:
+		before := generationValues{
+			Schemas: make(map[string]string, len(c.Current)),
+		}
+
+		for _, s := range c.Current {
+			before.Schemas[s.Name] = s.Version
+		}
+
+		for _, ex := range c.CurrentExemplars {
+			if before.Exemplars == nil {
+				before.Exemplars = make(map[string]string)
+			}
+
+			before.Exemplars[ex.Name] = ex.VersionHash
+		}
+
+		after := generationValues{
+			Activation: "active",
+			Schemas:    make(map[string]string, len(c.Schemas)),
+		}
+
+		if c.Activation == repository.SchemaActivation_ACTIVATION_PENDING {
+			after.Activation = "pending"
+		}
+
+		for _, s := range c.Schemas {
+			after.Schemas[s.Lock.Name] = s.Lock.Version
+		}
+
+		for _, ex := range c.Exemplars {
+			if after.Exemplars == nil {
+				after.Exemplars = make(map[string]string)
+			}
+
+			after.Exemplars[ex.Lock.Name] = ex.Lock.Hash
+		}
+
+		r.Before, r.After = before, after
+	case statusChange:
+		if c.Disable {
+			r.Before = c.Status
+		} else {
+			r.After = c.Status
+		}
+	case *DocWorkflowUpdate:
+		if c.Current != nil {
+			r.Before = c.Current
+		}
+
+		if c.Wanted != nil {
+			r.After = c.Wanted
+		}
+	case metaTypeChange:
+		switch c.Change {
+		case metaOpRegister, metaOpRegisterUse:
+			r.After = c.MetaType
+		case metaOpUnregister, metaOpUnregisterUse:
+			r.Before = c.MetaType
+		}
+	case *MetricUpdate:
+		if c.OldAggregation != "" {
+			r.Before = c.OldAggregation
+		}
+
+		if c.Aggregation != "" {
+			r.After = c.Aggregation
+		}
+	case *TypeConfigurationChange:
+		r.Before = newTypeConfigValues(c.Current)
+		r.After = newTypeConfigValues(c.Wanted)
+	}
+
+	return r
+}
@@ -0,0 +1,93 @@
+package eleconf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+func TestPlan_Report(t *testing.T) {
+	dir := t.TempDir()
+
+	writeHCL(t, dir, "config.hcl", `
+manage {
+  types   = ["core/author"]
+  metrics = ["wordcount"]
+  schemas = false
+}
+
+document "core/author" {
+  statuses = ["usable", "withheld"]
+}
+
+metric "wordcount" {
+  aggregation = "increment"
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	plan := fakePlan(t, conf, nil)
+
+	data, err := json.Marshal(plan.Report())
+	if err != nil {
+		t.Fatalf("marshal report: %v", err)
+	}
+
+	type change struct {
+		Domain    string
+		Operation string
+		Target    struct{ Kind, Name string }
+		Before    any
+		After     any
+	}
+
+	var report struct {
+		Changes []change
+		Drift   []change
+	}
+
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+
+	byDomain := make(map[string]change)
+
+	for _, c := range report.Changes {
+		byDomain[c.Domain] = c
+	}
+
+	status := byDomain["status"]
+
+	if len(report.Changes) != 2 || status.Operation != "add" ||
+		status.Target.Kind != "document_type" ||
+		status.Target.Name != "core/author" ||
+		status.Before != nil || status.After != "withheld" {
+		t.Errorf("unexpected status change in report: %s", data)
+	}
+
+	metric := byDomain["metric"]
+
+	if metric.Operation != "update" || metric.Target.Name != "wordcount" ||
+		metric.Before != "replace" || metric.After != "increment" {
+		t.Errorf("unexpected metric change in report: %s", data)
+	}
+
+	var driftMetrics []string
+
+	for _, c := range report.Drift {
+		if c.Domain == "metric" && c.Operation == "remove" &&
+			c.Before == "increment" {
+			driftMetrics = append(driftMetrics, c.Target.Name)
+		}
+	}
+
+	if len(driftMetrics) != 1 || driftMetrics[0] != "charcount" {
+		t.Errorf("expected the charcount removal as drift: %s", data)
+	}
+}
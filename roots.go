@@ -0,0 +1,207 @@
+package eleconf
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// CombineConfigs combines the configurations of several roots that are
+// applied to the same repository, f.ex. one for core editorial and one for
+// the print department. Each root owns what its manage block covers, the
+// manage blocks must not overlap, and the roots must not claim the same
+// document types, metric kinds or schemas.
+//
+// The combined configuration manages everything that the roots manage. Call
+// AddSchemaDocuments on the roots before combining them, as workflow
+// profiles and document defaults aren't combined.
+func CombineConfigs(roots []*Config) (*Config, hcl.Diagnostics) {
+	if len(roots) == 1 {
+		return roots[0], nil
+	}
+
+	combined := Config{
+		files: make(map[string]*hcl.File),
+		Manage: &ManageScope{
+			Types:   []string{},
+			Metrics: []string{},
+		},
+	}
+
+	var (
+		diags       hcl.Diagnostics
+		allTypes    bool
+		allMetrics  bool
+		schemaOwner *Config
+	)
+
+	for i, root := range roots {
+		maps.Copy(combined.files, root.files)
+
+		combined.Documents = append(combined.Documents, root.Documents...)
+		combined.SchemaSets = append(combined.SchemaSets, root.SchemaSets...)
+		combined.Metric = append(combined.Metric, root.Metric...)
+		combined.variables = append(combined.variables, root.variables...)
+
+		for j, other := range roots {
+			if i != j {
+				diags = append(diags, checkClaims(root, other)...)
+			}
+
+			if j > i {
+				diags = append(diags, checkOverlap(root, other)...)
+			}
+		}
+
+		scope := root.Manage
+		if scope == nil {
+			scope = &ManageScope{}
+		}
+
+		allTypes = allTypes || scope.Types == nil
+		allMetrics = allMetrics || scope.Metrics == nil
+
+		combined.Manage.Types = append(combined.Manage.Types, scope.Types...)
+		combined.Manage.Metrics = append(
+			combined.Manage.Metrics, scope.Metrics...)
+
+		if !scope.ManagesSchemas() {
+			continue
+		}
+
+		if schemaOwner != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Schemas claimed by multiple roots",
+				Detail: fmt.Sprintf(
+					"Schemas are managed by more than one configuration root, %s. Set schemas = false in the manage block of all roots but one.",
+					rootScopeName(schemaOwner)),
+				Subject: scopeRange(root),
+			})
+
+			continue
+		}
+
+		schemaOwner = root
+	}
+
+	if allTypes {
+		combined.Manage.Types = nil
+	}
+
+	if allMetrics {
+		combined.Manage.Metrics = nil
+	}
+
+	if schemaOwner == nil {
+		managed := false
+
+		combined.Manage.Schemas = &managed
+	}
+
+	return &combined, diags
+}
+
+// checkClaims checks that a root doesn't declare document types or metric
+// kinds that are managed by another root.
+func checkClaims(root *Config, other *Config) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, doc := range root.Documents {
+		for _, t := range []string{doc.Type, doc.MetaDocType} {
+			if t == "" || !other.Manage.ManagesType(t) {
+				continue
+			}
+
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Document type claimed by multiple roots",
+				Detail: fmt.Sprintf(
+					"The document type %q is also managed by %s.",
+					t, rootScopeName(other)),
+				Subject: doc.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	for _, m := range root.Metric {
+		if !other.Manage.ManagesMetric(m.Kind) {
+			continue
+		}
+
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Metric kind claimed by multiple roots",
+			Detail: fmt.Sprintf(
+				"The metric kind %q is also managed by %s.",
+				m.Kind, rootScopeName(other)),
+			Subject: m.DeclRange.Ptr(),
+		})
+	}
+
+	return diags
+}
+
+// checkOverlap checks that the manage blocks of two roots don't cover the
+// same document types or metric kinds, as each root would report what the
+// other declares as drift.
+func checkOverlap(root *Config, other *Config) hcl.Diagnostics {
+	var (
+		diags         hcl.Diagnostics
+		scope, oScope ManageScope
+	)
+
+	if root.Manage != nil {
+		scope = *root.Manage
+	}
+
+	if other.Manage != nil {
+		oScope = *other.Manage
+	}
+
+	if patternsOverlap(scope.Types, oScope.Types) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Overlapping document type scopes",
+			Detail: fmt.Sprintf(
+				"Some document types are managed both by %s and by %s. Every document type must be managed by one root.",
+				rootScopeName(root), rootScopeName(other)),
+			Subject: scopeRange(other),
+		})
+	}
+
+	if patternsOverlap(scope.Metrics, oScope.Metrics) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Overlapping metric kind scopes",
+			Detail: fmt.Sprintf(
+				"Some metric kinds are managed both by %s and by %s. Every metric kind must be managed by one root.",
+				rootScopeName(root), rootScopeName(other)),
+			Subject: scopeRange(other),
+		})
+	}
+
+	return diags
+}
+
+// rootScopeName describes a root by its manage block for diagnostics.
+func rootScopeName(root *Config) string {
+	if root.Manage == nil {
+		return "a configuration root without a manage block, which manages everything"
+	}
+
+	return fmt.Sprintf("the configuration root with the manage block at %s",
+		root.Manage.DeclRange)
+}
+
+func scopeRange(root *Config) *hcl.Range {
+	switch {
+	case root.Manage != nil:
+		return root.Manage.DeclRange.Ptr()
+	case len(root.SchemaSets) > 0:
+		return root.SchemaSets[0].DeclRange.Ptr()
+	default:
+		return nil
+	}
+}
@@ -1,6 +1,7 @@
 package eleconf
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
@@ -139,3 +140,67 @@ func (sc *SchemaCache) Store(
 
 	return nil
 }
+
+// LoadSchemaSetCached loads a locked schema set, using the cache for schemas
+// that have been loaded before. The schemas are loaded from their source and
+// stored in the cache if any of them are missing.
+func LoadSchemaSetCached(
+	ctx context.Context,
+	set SchemaSet,
+	lockfile *SchemaLockfile,
+	cache *SchemaCache,
+) ([]LoadedSchema, error) {
+	if lockfile == nil {
+		return nil, errors.New("missing lock file, run eleconf update")
+	}
+
+	var list []LoadedSchema
+
+	for _, name := range set.Schemas {
+		lock, ok := lockfile.Schemas[name]
+		if !ok {
+			break
+		}
+
+		data, ok, err := cache.Read(lock.URL,
+			cacheURL(set, lock), lock.Hash)
+		if err != nil || !ok {
+			break
+		}
+
+		list = append(list, LoadedSchema{
+			Lock: lock,
+			Data: data,
+		})
+	}
+
+	if len(list) == len(set.Schemas) {
+		return list, nil
+	}
+
+	list, err := LoadSchemaSet(ctx, set, lockfile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range list {
+		err := cache.Store(s.Lock.URL, cacheURL(set, s.Lock),
+			s.Lock.Hash, s.Data)
+		if err != nil {
+			return nil, fmt.Errorf("cache schema %q: %w", s.Lock.Name, err)
+		}
+	}
+
+	return list, nil
+}
+
+// cacheURL returns the logical URL of a schema in the cache. Schemas loaded
+// from git repositories don't have a URL of their own.
+func cacheURL(set SchemaSet, lock SchemaLock) string {
+	if lock.URL != "" {
+		return lock.URL
+	}
+
+	return strings.TrimSuffix(set.Repository, "/") +
+		"/" + lock.Version + "/" + lock.Name + ".json"
+}
@@ -4,16 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	rpcdoc "github.com/ttab/elephant-api/newsdoc"
 	"github.com/ttab/elephant-api/repository"
 	"github.com/ttab/revisor"
+	"golang.org/x/mod/semver"
 )
 
 // GetSchemaChanges computes the schema changes needed to bring the remote
 // state in line with the desired configuration. With schema generations,
 // this produces at most one change: a generationChange that registers
-// all schemas as a generation.
+// all schemas as a generation. Like the other domains the change is
+// computed even if the configuration doesn't manage schemas, PlanChanges
+// then reports it as drift.
 func GetSchemaChanges(
 	ctx context.Context,
 	clients Clients,
@@ -31,9 +36,13 @@ func GetSchemaChanges(
 			"get active schemas: %w", err)
 	}
 
-	err = checkDocsDefined(loaded, conf.Documents)
-	if err != nil {
-		return nil, err
+	// The schemas of another owner aren't loaded, so there is nothing
+	// to check the documents against.
+	if conf.Manage.ManagesSchemas() {
+		err = checkDocsDefined(loaded, conf.Documents)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var currentExemplars []*repository.Exemplar
@@ -116,6 +125,11 @@ type generationChange struct {
 	CurrentExemplars []*repository.Exemplar
 }
 
+// Target implements TargetedChange.
+func (gc generationChange) Target() ChangeTarget {
+	return ChangeTarget{Kind: TargetSchemas}
+}
+
 func (gc generationChange) Describe() (ChangeOp, string) {
 	op := OpUpdate
 
@@ -190,6 +204,85 @@ func (gc generationChange) Describe() (ChangeOp, string) {
 	return op, desc
 }
 
+// Warnings implements the optional warnings of a change, listing schema
+// downgrades.
+func (gc generationChange) Warnings() []string {
+	return gc.downgrades()
+}
+
+// removals describes the current schemas and exemplars that aren't part of
+// the new generation.
+func (gc generationChange) removals() []string {
+	var list []string
+
+	for _, s := range gc.Current {
+		if !slices.ContainsFunc(gc.Schemas, func(l LoadedSchema) bool {
+			return l.Lock.Name == s.Name
+		}) {
+			list = append(list, fmt.Sprintf(
+				"removing schema %s %s", s.Name, s.Version))
+		}
+	}
+
+	for _, ex := range gc.CurrentExemplars {
+		if !slices.ContainsFunc(gc.Exemplars, func(l LoadedExemplar) bool {
+			return l.Lock.Name == ex.Name
+		}) {
+			list = append(list, fmt.Sprintf(
+				"removing exemplar %s", ex.Name))
+		}
+	}
+
+	return list
+}
+
+// downgrades describes the schemas that get an older, or an incomparable,
+// version.
+func (gc generationChange) downgrades() []string {
+	current := make(map[string]string, len(gc.Current))
+
+	for _, s := range gc.Current {
+		current[s.Name] = s.Version
+	}
+
+	var list []string
+
+	for _, s := range gc.Schemas {
+		curr, ok := current[s.Lock.Name]
+		if !ok || curr == s.Lock.Version {
+			continue
+		}
+
+		c, ok := compareVersions(s.Lock.Version, curr)
+
+		switch {
+		case !ok:
+			list = append(list, fmt.Sprintf(
+				"can't compare schema versions %s %s => %s",
+				s.Lock.Name, curr, s.Lock.Version))
+		case c < 0:
+			list = append(list, fmt.Sprintf(
+				"downgrading schema %s %s => %s",
+				s.Lock.Name, curr, s.Lock.Version))
+		}
+	}
+
+	return list
+}
+
+// compareVersions compares two semantic versions, with or without a "v"
+// prefix. Returns false if either version isn't a valid semantic version.
+func compareVersions(a, b string) (int, bool) {
+	a = "v" + strings.TrimPrefix(a, "v")
+	b = "v" + strings.TrimPrefix(b, "v")
+
+	if !semver.IsValid(a) || !semver.IsValid(b) {
+		return 0, false
+	}
+
+	return semver.Compare(a, b), true
+}
+
 func (gc generationChange) Execute(
 	ctx context.Context,
 	clients Clients,
@@ -231,24 +324,9 @@ func checkDocsDefined(
 	schemas []LoadedSchema,
 	docs []DocumentConfig,
 ) error {
-	definedDocTypes := make(map[string]bool)
-
-	for _, schema := range schemas {
-		var cs revisor.ConstraintSet
-
-		err := json.Unmarshal(schema.Data, &cs)
-		if err != nil {
-			return fmt.Errorf("invalid schema %s@%s",
-				schema.Lock.Name, schema.Lock.Version)
-		}
-
-		for _, ds := range cs.Documents {
-			if ds.Declares == "" {
-				continue
-			}
-
-			definedDocTypes[ds.Declares] = true
-		}
+	definedDocTypes, err := DeclaredDocumentTypes(schemas)
+	if err != nil {
+		return err
 	}
 
 	for _, dc := range docs {
@@ -269,3 +347,29 @@ func checkDocsDefined(
 
 	return nil
 }
+
+// DeclaredDocumentTypes returns the document types that are declared by the
+// schemas.
+func DeclaredDocumentTypes(schemas []LoadedSchema) (map[string]bool, error) {
+	declared := make(map[string]bool)
+
+	for _, schema := range schemas {
+		var cs revisor.ConstraintSet
+
+		err := json.Unmarshal(schema.Data, &cs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %s@%s",
+				schema.Lock.Name, schema.Lock.Version)
+		}
+
+		for _, ds := range cs.Documents {
+			if ds.Declares == "" {
+				continue
+			}
+
+			declared[ds.Declares] = true
+		}
+	}
+
+	return declared, nil
+}
@@ -62,9 +62,14 @@ func GetStatusChanges(
 var _ ConfigurationChange = statusChange{}
 
 type statusChange struct {
-	Type    string
-	Status  string
-	Disable bool
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Disable bool   `json:"disable,omitempty"`
+}
+
+// Target implements TargetedChange.
+func (s statusChange) Target() ChangeTarget {
+	return ChangeTarget{Kind: TargetDocumentType, Name: s.Type}
 }
 
 // Describe implements ConfigurationChange.
@@ -0,0 +1,422 @@
+package eleconf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/ttab/newsdoc"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type DiagnosticSeverity string
+
+const (
+	DiagnosticError   DiagnosticSeverity = "error"
+	DiagnosticWarning DiagnosticSeverity = "warning"
+)
+
+// Diagnostic describes a problem found in the configuration.
+type Diagnostic struct {
+	Severity DiagnosticSeverity
+	// Block identifies the configuration block the problem was found
+	// in, f.ex. `document "core/article"`.
+	Block   string
+	Summary string
+	Detail  string
+	// Subject is the source range of the offending attribute, or of the
+	// block if the attribute wasn't set in it, if known.
+	Subject *hcl.Range
+}
+
+func (d Diagnostic) String() string {
+	msg := fmt.Sprintf("%s: %s: %s", d.Severity, d.Block, d.Summary)
+
+	if d.Subject != nil {
+		msg = d.Subject.String() + ": " + msg
+	}
+
+	if d.Detail != "" {
+		msg += ": " + d.Detail
+	}
+
+	return msg
+}
+
+// HCL converts the diagnostic to a HCL diagnostic.
+func (d Diagnostic) HCL() *hcl.Diagnostic {
+	severity := hcl.DiagError
+	if d.Severity == DiagnosticWarning {
+		severity = hcl.DiagWarning
+	}
+
+	return &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  d.Block + ": " + d.Summary,
+		Detail:   d.Detail,
+		Subject:  d.Subject,
+	}
+}
+
+// HCLDiagnostics converts a list of diagnostics to HCL diagnostics.
+func HCLDiagnostics(diags []Diagnostic) hcl.Diagnostics {
+	res := make(hcl.Diagnostics, len(diags))
+
+	for i := range diags {
+		res[i] = diags[i].HCL()
+	}
+
+	return res
+}
+
+// HasErrors returns true if any of the diagnostics is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == DiagnosticError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate runs semantic checks on the configuration that don't require
+// access to the repository or the schemas. All problems are reported, not
+// just the first one.
+func Validate(conf *Config) []Diagnostic {
+	var diags []Diagnostic
+
+	for _, doc := range conf.Documents {
+		diags = append(diags, validateDocument(doc)...)
+	}
+
+	for _, set := range conf.SchemaSets {
+		diags = append(diags, validateSchemaSet(set)...)
+	}
+
+	for _, m := range conf.Metric {
+		switch m.Aggregation {
+		case "", MetricAggregationIncrement, MetricAggregationReplace:
+		default:
+			diags = append(diags, Diagnostic{
+				Severity: DiagnosticError,
+				Block:    fmt.Sprintf("metric %q", m.Kind),
+				Summary:  fmt.Sprintf("unknown aggregation %q", m.Aggregation),
+				Subject:  m.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+func validateDocument(doc DocumentConfig) []Diagnostic {
+	var diags []Diagnostic
+
+	block := fmt.Sprintf("document %q", doc.Type)
+	ranges := newDocumentRanges(doc)
+
+	report := func(
+		sev DiagnosticSeverity, subject *hcl.Range,
+		summary string, args ...any,
+	) {
+		diags = append(diags, Diagnostic{
+			Severity: sev,
+			Block:    block,
+			Summary:  fmt.Sprintf(summary, args...),
+			Subject:  subject,
+		})
+	}
+
+	seen := make(map[string]bool, len(doc.Statuses))
+
+	for _, s := range doc.Statuses {
+		if seen[s] {
+			report(DiagnosticWarning, ranges.attribute("statuses"),
+				"status %q is listed more than once", s)
+		}
+
+		seen[s] = true
+	}
+
+	_, err := doc.EvictionPeriod()
+	if err != nil {
+		report(DiagnosticError, ranges.attribute("evict_noncurrent_after"),
+			"invalid evict_noncurrent_after: %v", err)
+	}
+
+	if wf := doc.Workflow; wf != nil {
+		checkStatus := func(attr string, name string, status string) {
+			subject := ranges.workflowAttribute(attr)
+
+			switch {
+			case status == "":
+				report(DiagnosticError, subject,
+					"workflow %s must be set", name)
+			case !seen[status]:
+				report(DiagnosticError, subject,
+					"workflow %s %q is not one of the document statuses",
+					name, status)
+			}
+		}
+
+		checkStatus("step_zero", "step_zero", wf.StepZero)
+		checkStatus("checkpoint", "checkpoint", wf.Checkpoint)
+
+		for _, step := range wf.Steps {
+			checkStatus("steps", "step", step)
+		}
+
+		if len(wf.Steps) > 0 && wf.StepZero != "" &&
+			!slices.Contains(wf.Steps, wf.StepZero) {
+			report(DiagnosticWarning, ranges.workflowAttribute("step_zero"),
+				"workflow step_zero %q is not one of the workflow steps",
+				wf.StepZero)
+		}
+
+		negative := ranges.workflowAttribute("negative_checkpoint")
+
+		// An empty negative checkpoint is accepted by the repository,
+		// but documents can't be withdrawn through the workflow.
+		switch {
+		case wf.NegativeCheckpoint == "":
+			report(DiagnosticWarning, negative,
+				"workflow negative_checkpoint is not set")
+		case wf.NegativeCheckpoint == wf.Checkpoint:
+			report(DiagnosticError, negative,
+				"workflow negative_checkpoint %q must differ from the checkpoint",
+				wf.NegativeCheckpoint)
+		case slices.Contains(wf.Steps, wf.NegativeCheckpoint):
+			report(DiagnosticError, negative,
+				"workflow negative_checkpoint %q must not be a workflow step",
+				wf.NegativeCheckpoint)
+		}
+	}
+
+	for _, exp := range doc.TimeExpressions {
+		_, err := newsdoc.ValueExtractorFromString(exp.Expression)
+		if err != nil {
+			report(DiagnosticError, ranges.expressionAttribute(
+				"time_expression", exp.Expression, "expression"),
+				"invalid time expression %q: %v", exp.Expression, err)
+		}
+
+		if exp.Timezone != "" {
+			_, err := time.LoadLocation(exp.Timezone)
+			if err != nil {
+				report(DiagnosticError, ranges.expressionAttribute(
+					"time_expression", exp.Expression, "timezone"),
+					"invalid time expression timezone %q: %v",
+					exp.Timezone, err)
+			}
+		}
+	}
+
+	for _, exp := range doc.LabelExpressions {
+		_, err := newsdoc.ValueExtractorFromString(exp.Expression)
+		if err != nil {
+			report(DiagnosticError, ranges.expressionAttribute(
+				"label_expression", exp.Expression, "expression"),
+				"invalid label expression %q: %v", exp.Expression, err)
+		}
+
+		_, err = template.New("label").Parse(exp.Template)
+		if err != nil {
+			report(DiagnosticError, ranges.expressionAttribute(
+				"label_expression", exp.Expression, "template"),
+				"invalid label template %q: %v", exp.Template, err)
+		}
+	}
+
+	for _, att := range doc.Attachments {
+		for _, mt := range att.MatchMimetype {
+			if !strings.Contains(mt, "/") {
+				report(DiagnosticError, ranges.attachmentAttribute(
+					att.Name, "match_mimetype"),
+					"attachment %q has an invalid mimetype %q",
+					att.Name, mt)
+			}
+		}
+	}
+
+	return diags
+}
+
+// documentRangesSchema lists the parts of a document block that diagnostics
+// can point at.
+var documentRangesSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "statuses"},
+		{Name: "workflow"},
+		{Name: "evict_noncurrent_after"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "time_expression"},
+		{Type: "label_expression"},
+		{Type: "attachment", LabelNames: []string{"name"}},
+	},
+}
+
+// documentRanges finds the source ranges of the settings of a document
+// block. Settings that weren't set in the block itself, f.ex. ones that
+// come from a profile or an extension, fall back to the block.
+type documentRanges struct {
+	block  hcl.Range
+	attrs  hcl.Attributes
+	blocks hcl.Blocks
+}
+
+func newDocumentRanges(doc DocumentConfig) documentRanges {
+	r := documentRanges{block: doc.DeclRange}
+
+	if doc.Body == nil {
+		return r
+	}
+
+	// The body has already been decoded, so any diagnostics have been
+	// reported.
+	content, _, _ := doc.Body.PartialContent(documentRangesSchema)
+	if content != nil {
+		r.attrs = content.Attributes
+		r.blocks = content.Blocks
+	}
+
+	return r
+}
+
+func (r documentRanges) attribute(name string) *hcl.Range {
+	attr, ok := r.attrs[name]
+	if !ok {
+		return r.block.Ptr()
+	}
+
+	return attr.Range.Ptr()
+}
+
+// workflowAttribute returns the range of an attribute of the workflow
+// object, or of the workflow if it isn't an object constructor.
+func (r documentRanges) workflowAttribute(name string) *hcl.Range {
+	attr, ok := r.attrs["workflow"]
+	if !ok {
+		return r.block.Ptr()
+	}
+
+	pairs, diags := hcl.ExprMap(attr.Expr)
+	if diags.HasErrors() {
+		return attr.Range.Ptr()
+	}
+
+	for _, pair := range pairs {
+		if stringValue(pair.Key) != name {
+			continue
+		}
+
+		rng := hcl.RangeBetween(pair.Key.Range(), pair.Value.Range())
+
+		return &rng
+	}
+
+	return attr.Range.Ptr()
+}
+
+// expressionAttribute returns the range of an attribute of the time or
+// label expression block with the given expression.
+func (r documentRanges) expressionAttribute(
+	blockType string, expression string, name string,
+) *hcl.Range {
+	match := func(attrs hcl.Attributes, _ []string) bool {
+		exp, ok := attrs["expression"]
+
+		return ok && stringValue(exp.Expr) == expression
+	}
+
+	return r.blockAttribute(blockType, name, match)
+}
+
+// attachmentAttribute returns the range of an attribute of the named
+// attachment block.
+func (r documentRanges) attachmentAttribute(
+	attachment string, name string,
+) *hcl.Range {
+	match := func(_ hcl.Attributes, labels []string) bool {
+		return labels[0] == attachment
+	}
+
+	return r.blockAttribute("attachment", name, match)
+}
+
+// blockAttribute returns the range of an attribute of the first block of
+// the given type that matches, or of the block if the attribute isn't set.
+func (r documentRanges) blockAttribute(
+	blockType string, name string,
+	match func(attrs hcl.Attributes, labels []string) bool,
+) *hcl.Range {
+	for _, block := range r.blocks {
+		if block.Type != blockType {
+			continue
+		}
+
+		attrs, _ := block.Body.JustAttributes()
+
+		if !match(attrs, block.Labels) {
+			continue
+		}
+
+		attr, ok := attrs[name]
+		if !ok {
+			return block.DefRange.Ptr()
+		}
+
+		return attr.Range.Ptr()
+	}
+
+	return r.block.Ptr()
+}
+
+// stringValue returns the value of a literal string expression, or an empty
+// string if the expression can't be evaluated without a context.
+func stringValue(expr hcl.Expression) string {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() ||
+		v.Type() != cty.String {
+		return ""
+	}
+
+	return v.AsString()
+}
+
+func validateSchemaSet(set SchemaSet) []Diagnostic {
+	var diags []Diagnostic
+
+	report := func(summary string, args ...any) {
+		diags = append(diags, Diagnostic{
+			Severity: DiagnosticError,
+			Block:    fmt.Sprintf("schema_set %q", set.Name),
+			Summary:  fmt.Sprintf(summary, args...),
+			Subject:  set.DeclRange.Ptr(),
+		})
+	}
+
+	switch {
+	case set.URLTemplate != "" && set.Repository != "":
+		report("only one of url_template and repository can be set")
+	case strings.HasPrefix(set.URLTemplate, "https://"):
+		_, err := template.New("url").Parse(set.URLTemplate)
+		if err != nil {
+			report("invalid url_template: %v", err)
+		}
+	case set.URLTemplate != "":
+		report("url_template must be a https:// URL")
+	case set.Repository == "":
+		report("either url_template or repository must be set")
+	}
+
+	if len(set.Schemas) == 0 {
+		report("no schemas listed")
+	}
+
+	return diags
+}
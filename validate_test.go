@@ -0,0 +1,148 @@
+package eleconf_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/eleconf"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+document "core/article" {
+  statuses = ["draft", "done", "usable"]
+  workflow = {
+    step_zero  = "draft"
+    checkpoint = "published"
+    negative_checkpoint = "done"
+    steps = ["draft", "done", "approved"]
+  }
+
+  time_expression {
+    expression = ".meta(type='core/event').data{start, end}"
+    timezone   = "Europe/Nowhere"
+  }
+
+  label_expression {
+    expression = ".links(rel='section')@{uuid}"
+    template   = "section:{{.uuid.Value"
+  }
+}
+
+document "core/event" {
+  statuses = ["draft", "usable"]
+  workflow = {
+    step_zero  = "draft"
+    checkpoint = "usable"
+    negative_checkpoint = "unpublished"
+    steps = ["draft"]
+  }
+}
+
+schema_set "core" {
+  version = "v1.0.0"
+  schemas = ["core"]
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	diags := eleconf.Validate(conf)
+
+	var got []string
+
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+
+	want := []string{
+		`checkpoint "published" is not one of the document statuses`,
+		`step "approved" is not one of the document statuses`,
+		`negative_checkpoint "done" must not be a workflow step`,
+		`invalid time expression timezone "Europe/Nowhere"`,
+		`invalid label template`,
+		`either url_template or repository must be set`,
+	}
+
+	for _, w := range want {
+		found := slices.ContainsFunc(got, func(s string) bool {
+			return strings.Contains(s, w)
+		})
+		if !found {
+			t.Errorf("missing diagnostic %q in:\n%s",
+				w, strings.Join(got, "\n"))
+		}
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("expected %d diagnostics, got %d:\n%s",
+			len(want), len(got), strings.Join(got, "\n"))
+	}
+
+	if !eleconf.HasErrors(diags) {
+		t.Error("expected HasErrors to be true")
+	}
+}
+
+func TestValidate_AttributeRanges(t *testing.T) {
+	dir := t.TempDir()
+	writeHCL(t, dir, "test.hcl", `
+document "core/article" {
+  statuses = ["draft", "usable", "usable"]
+  workflow = {
+    step_zero  = "draft"
+    checkpoint = "usable"
+    steps      = ["draft"]
+
+    negative_checkpoint = ""
+  }
+
+  label_expression {
+    expression = ".links(rel='section')@{uuid}"
+    template   = "section:{{.uuid.Value"
+  }
+}
+`)
+
+	conf, err := eleconf.ReadConfigFromDirectory(dir)
+	if err != nil {
+		t.Fatalf("read configuration: %v", err)
+	}
+
+	diags := eleconf.Validate(conf)
+
+	lines := map[string]int{
+		"listed more than once":          3,
+		"negative_checkpoint is not set": 9,
+		"invalid label template":         14,
+	}
+
+	for _, d := range diags {
+		for summary, line := range lines {
+			if !strings.Contains(d.Summary, summary) {
+				continue
+			}
+
+			delete(lines, summary)
+
+			if summary == "negative_checkpoint is not set" &&
+				d.Severity != eleconf.DiagnosticWarning {
+				t.Errorf("expected %q to be a warning", d.Summary)
+			}
+
+			if d.Subject == nil || d.Subject.Start.Line != line {
+				t.Errorf("expected %q at line %d, got %v",
+					d.Summary, line, d.Subject)
+			}
+		}
+	}
+
+	for summary := range lines {
+		t.Errorf("missing diagnostic %q in: %v", summary, diags)
+	}
+}
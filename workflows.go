@@ -101,10 +101,15 @@ var _ ConfigurationChange = &DocWorkflowUpdate{}
 type DocWorkflowUpdate struct {
 	diff string
 
-	Operation ChangeOp
-	Type      string
-	Current   *DocumentWorkflow
-	Wanted    *DocumentWorkflow
+	Operation ChangeOp          `json:"operation"`
+	Type      string            `json:"type"`
+	Current   *DocumentWorkflow `json:"current,omitempty"`
+	Wanted    *DocumentWorkflow `json:"wanted,omitempty"`
+}
+
+// Target implements TargetedChange.
+func (d *DocWorkflowUpdate) Target() ChangeTarget {
+	return ChangeTarget{Kind: TargetDocumentType, Name: d.Type}
 }
 
 // Describe implements ConfigurationChange.